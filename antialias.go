@@ -0,0 +1,182 @@
+package Netpbm
+
+import "math"
+
+// polygonSubsamples is the number of sub-scanlines per pixel row used to measure the vertical
+// coverage of polygon edges.
+const polygonSubsamples = 16
+
+// blendAt mixes color into the pixel at (x, y) with the given coverage in [0, 1]. Pixels
+// outside of the image are ignored.
+func (ppm *PPM) blendAt(x, y int, color Pixel, coverage float64) {
+	if x < 0 || y < 0 || x >= ppm.width || y >= ppm.height || coverage <= 0 {
+		return
+	}
+	coverage = math.Min(coverage, 1)
+	p := ppm.data[y][x]
+	mix := func(d, s uint8) uint8 {
+		return clampToMax(float64(d)+(float64(s)-float64(d))*coverage, ppm.max)
+	}
+	ppm.data[y][x] = Pixel{mix(p.R, color.R), mix(p.G, color.G), mix(p.B, color.B)}
+}
+
+// blendAt mixes value into the pixel at (x, y) with the given coverage in [0, 1]. Pixels
+// outside of the image are ignored.
+func (pgm *PGM) blendAt(x, y int, value uint8, coverage float64) {
+	if x < 0 || y < 0 || x >= pgm.width || y >= pgm.height || coverage <= 0 {
+		return
+	}
+	coverage = math.Min(coverage, 1)
+	d := float64(pgm.data[y][x])
+	pgm.data[y][x] = clampToMax(d+(float64(value)-d)*coverage, pgm.max)
+}
+
+// wuLine calls plot for the pixels along the segment with Xiaolin Wu's algorithm: at every
+// step along the major axis the two pixels straddling the line share the coverage according
+// to their distance to it.
+func wuLine(p1, p2 Point, plot func(x, y int, coverage float64)) {
+	x0, y0, x1, y1 := float64(p1.X), float64(p1.Y), float64(p2.X), float64(p2.Y)
+	steep := math.Abs(y1-y0) > math.Abs(x1-x0)
+	if steep {
+		x0, y0, x1, y1 = y0, x0, y1, x1
+	}
+	if x0 > x1 {
+		x0, y0, x1, y1 = x1, y1, x0, y0
+	}
+	gradient := 0.0
+	if x1 > x0 {
+		gradient = (y1 - y0) / (x1 - x0)
+	}
+	for x := int(x0); x <= int(x1); x++ {
+		y := y0 + gradient*(float64(x)-x0)
+		iy, frac := int(math.Floor(y)), y-math.Floor(y)
+		if steep {
+			plot(iy, x, 1-frac)
+			plot(iy+1, x, frac)
+		} else {
+			plot(x, iy, 1-frac)
+			plot(x, iy+1, frac)
+		}
+	}
+}
+
+// circleCoverage calls plot for the pixels near the circle with their coverage, computed from
+// their distance to the circumference for an outline one pixel wide, or to the disk when filled.
+func circleCoverage(center Point, radius int, filled bool, plot func(x, y int, coverage float64)) {
+	r := float64(radius)
+	for y := center.Y - radius - 1; y <= center.Y+radius+1; y++ {
+		for x := center.X - radius - 1; x <= center.X+radius+1; x++ {
+			d := math.Hypot(float64(x-center.X), float64(y-center.Y))
+			coverage := 1 - math.Abs(d-r)
+			if filled {
+				coverage = r + 0.5 - d // Signed distance to the disk edge, shifted to the pixel center.
+			}
+			plot(x, y, math.Min(math.Max(coverage, 0), 1))
+		}
+	}
+}
+
+// polygonCoverage returns the fraction of each pixel of a width x height image covered by the
+// polygons with the fill rule. Each pixel is the unit square centered on its coordinates;
+// rows are sampled with sub-scanlines and the spans between edge crossings are accumulated
+// with exact horizontal coverage.
+func polygonCoverage(polygons [][]Point, rule FillRule, width, height int) [][]float64 {
+	coverage := newPlane(width, height)
+	weight := 1.0 / polygonSubsamples
+	scanPolygon(toRings(polygons), rule, -0.5+weight/2, weight, height*polygonSubsamples, func(row int, x0, x1 float64) {
+		addSpan(coverage[row/polygonSubsamples], x0+0.5, x1+0.5, weight)
+	})
+	return coverage
+}
+
+// addSpan adds weight times the covered fraction of each cell of row for the span [a, b),
+// cell i spanning [i, i+1).
+func addSpan(row []float64, a, b, weight float64) {
+	a, b = math.Max(a, 0), math.Min(b, float64(len(row)))
+	for i := int(a); i < len(row) && float64(i) < b; i++ {
+		row[i] += weight * (math.Min(float64(i+1), b) - math.Max(float64(i), a))
+	}
+}
+
+// DrawAntialiasedLine draws an anti-aliased line between two points, blending it with the
+// existing pixels.
+func (ppm *PPM) DrawAntialiasedLine(p1, p2 Point, color Pixel) {
+	wuLine(p1, p2, func(x, y int, coverage float64) { ppm.blendAt(x, y, color, coverage) })
+}
+
+// DrawAntialiasedCircle draws an anti-aliased circle outline.
+func (ppm *PPM) DrawAntialiasedCircle(center Point, radius int, color Pixel) {
+	circleCoverage(center, radius, false, func(x, y int, coverage float64) { ppm.blendAt(x, y, color, coverage) })
+}
+
+// DrawAntialiasedFilledCircle draws an anti-aliased disk.
+func (ppm *PPM) DrawAntialiasedFilledCircle(center Point, radius int, color Pixel) {
+	circleCoverage(center, radius, true, func(x, y int, coverage float64) { ppm.blendAt(x, y, color, coverage) })
+}
+
+// DrawAntialiasedPolygon draws the anti-aliased outline of a polygon.
+func (ppm *PPM) DrawAntialiasedPolygon(points []Point, color Pixel) {
+	if len(points) < 3 {
+		return
+	}
+	for i := range points {
+		ppm.DrawAntialiasedLine(points[i], points[(i+1)%len(points)], color)
+	}
+}
+
+// DrawAntialiasedFilledPolygon fills a polygon with the nonzero rule, like DrawFilledPolygon,
+// blending its edge pixels according to how much of them the polygon covers.
+func (ppm *PPM) DrawAntialiasedFilledPolygon(points []Point, color Pixel) {
+	ppm.DrawAntialiasedFilledPolygons([][]Point{points}, color, FillNonZero)
+}
+
+// DrawAntialiasedFilledPolygons fills the region enclosed by several polygons with the fill
+// rule and anti-aliased edges, see DrawFilledPolygons.
+func (ppm *PPM) DrawAntialiasedFilledPolygons(polygons [][]Point, color Pixel, rule FillRule) {
+	for y, row := range polygonCoverage(polygons, rule, ppm.width, ppm.height) {
+		for x, coverage := range row {
+			ppm.blendAt(x, y, color, coverage)
+		}
+	}
+}
+
+// DrawAntialiasedLine draws an anti-aliased line between two points on the PGM image.
+func (pgm *PGM) DrawAntialiasedLine(p1, p2 Point, value uint8) {
+	wuLine(p1, p2, func(x, y int, coverage float64) { pgm.blendAt(x, y, value, coverage) })
+}
+
+// DrawAntialiasedCircle draws an anti-aliased circle outline on the PGM image.
+func (pgm *PGM) DrawAntialiasedCircle(center Point, radius int, value uint8) {
+	circleCoverage(center, radius, false, func(x, y int, coverage float64) { pgm.blendAt(x, y, value, coverage) })
+}
+
+// DrawAntialiasedFilledCircle draws an anti-aliased disk on the PGM image.
+func (pgm *PGM) DrawAntialiasedFilledCircle(center Point, radius int, value uint8) {
+	circleCoverage(center, radius, true, func(x, y int, coverage float64) { pgm.blendAt(x, y, value, coverage) })
+}
+
+// DrawAntialiasedPolygon draws the anti-aliased outline of a polygon on the PGM image.
+func (pgm *PGM) DrawAntialiasedPolygon(points []Point, value uint8) {
+	if len(points) < 3 {
+		return
+	}
+	for i := range points {
+		pgm.DrawAntialiasedLine(points[i], points[(i+1)%len(points)], value)
+	}
+}
+
+// DrawAntialiasedFilledPolygon fills a polygon on the PGM image with the nonzero rule and
+// anti-aliased edges.
+func (pgm *PGM) DrawAntialiasedFilledPolygon(points []Point, value uint8) {
+	pgm.DrawAntialiasedFilledPolygons([][]Point{points}, value, FillNonZero)
+}
+
+// DrawAntialiasedFilledPolygons fills the region enclosed by several polygons on the PGM image
+// with the fill rule and anti-aliased edges.
+func (pgm *PGM) DrawAntialiasedFilledPolygons(polygons [][]Point, value uint8, rule FillRule) {
+	for y, row := range polygonCoverage(polygons, rule, pgm.width, pgm.height) {
+		for x, coverage := range row {
+			pgm.blendAt(x, y, value, coverage)
+		}
+	}
+}
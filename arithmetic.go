@@ -0,0 +1,182 @@
+package Netpbm
+
+import (
+	"fmt"
+	"math"
+)
+
+// combinePlanes applies f to every pair of samples of the planes of a and b and stores the
+// results in a. When rescale is true and some results fall outside of [0, max], all of them are
+// mapped linearly from [min(lowest, 0), max(highest, max)] onto [0, max]. The range is shared by
+// all the planes so that the channels of a color keep their proportions. Otherwise the results
+// are left as they are and saturate when stored.
+func combinePlanes(a, b [][][]float64, max uint8, rescale bool, f func(a, b float64) float64) {
+	lo, hi := 0.0, float64(max)
+	for c := range a {
+		for y := range a[c] {
+			for x := range a[c][y] {
+				a[c][y][x] = f(a[c][y][x], b[c][y][x])
+				lo, hi = math.Min(lo, a[c][y][x]), math.Max(hi, a[c][y][x])
+			}
+		}
+	}
+	if !rescale || (lo >= 0 && hi <= float64(max)) || hi <= lo {
+		return
+	}
+	for c := range a {
+		for y := range a[c] {
+			for x := range a[c][y] {
+				a[c][y][x] = (a[c][y][x] - lo) / (hi - lo) * float64(max)
+			}
+		}
+	}
+}
+
+// scaledPlane returns the plane scaled from the max value from to the max value to, so that
+// images with different max values can be combined.
+func scaledPlane(plane [][]float64, from, to uint8) [][]float64 {
+	if from == to || from == 0 {
+		return plane
+	}
+	for y := range plane {
+		for x := range plane[y] {
+			plane[y][x] = plane[y][x] * float64(to) / float64(from)
+		}
+	}
+	return plane
+}
+
+// combine returns a new PGM image holding f applied to the pixels of pgm and other. The values
+// of other are first brought to the max value of pgm.
+func (pgm *PGM) combine(other *PGM, rescale bool, f func(a, b float64) float64) (*PGM, error) {
+	if other.width != pgm.width || other.height != pgm.height {
+		return nil, fmt.Errorf("image sizes differ: %dx%d and %dx%d", pgm.width, pgm.height, other.width, other.height)
+	}
+	a := pgm.plane()
+	combinePlanes([][][]float64{a}, [][][]float64{scaledPlane(other.plane(), other.max, pgm.max)}, pgm.max, rescale, f)
+	out := newPGM(pgm.width, pgm.height, pgm.magicNumber, pgm.max)
+	out.setPlane(a)
+	return out, nil
+}
+
+// combine returns a new PPM image holding f applied to each channel of the pixels of ppm and
+// other. The values of other are first brought to the max value of ppm.
+func (ppm *PPM) combine(other *PPM, rescale bool, f func(a, b float64) float64) (*PPM, error) {
+	if other.width != ppm.width || other.height != ppm.height {
+		return nil, fmt.Errorf("image sizes differ: %dx%d and %dx%d", ppm.width, ppm.height, other.width, other.height)
+	}
+	a, b := ppm.planes(), other.planes()
+	for c := range b {
+		b[c] = scaledPlane(b[c], other.max, ppm.max)
+	}
+	combinePlanes(a[:], b[:], ppm.max, rescale, f)
+	out := newPPM(ppm.width, ppm.height, ppm.magicNumber, ppm.max)
+	out.setPlanes(a)
+	return out, nil
+}
+
+func add(a, b float64) float64      { return a + b }
+func subtract(a, b float64) float64 { return a - b }
+func absDiff(a, b float64) float64  { return math.Abs(a - b) }
+func average(a, b float64) float64  { return (a + b) / 2 }
+
+// multiplyBy returns the product of two values normalized by max, so that multiplying by max
+// leaves a value unchanged.
+func multiplyBy(max uint8) func(a, b float64) float64 {
+	return func(a, b float64) float64 {
+		return a * b / float64(max)
+	}
+}
+
+// blendBy returns the weighted mean giving weight to the second value.
+func blendBy(weight float64) func(a, b float64) float64 {
+	weight = math.Min(math.Max(weight, 0), 1)
+	return func(a, b float64) float64 {
+		return (1-weight)*a + weight*b
+	}
+}
+
+// Add returns the sum of the PGM images, saturating at max unless rescale is true, in which
+// case the sums are scaled down to fit [0, max] when some of them overflow. All the arithmetic
+// methods require images of the same size and bring other to the max value of pgm.
+func (pgm *PGM) Add(other *PGM, rescale bool) (*PGM, error) {
+	return pgm.combine(other, rescale, add)
+}
+
+// Subtract returns pgm minus other, saturating at 0 unless rescale is true, in which case the
+// differences are shifted and scaled to fit [0, max] when some of them are negative.
+func (pgm *PGM) Subtract(other *PGM, rescale bool) (*PGM, error) {
+	return pgm.combine(other, rescale, subtract)
+}
+
+// AbsDiff returns the absolute difference of the PGM images.
+func (pgm *PGM) AbsDiff(other *PGM, rescale bool) (*PGM, error) {
+	return pgm.combine(other, rescale, absDiff)
+}
+
+// Multiply returns the product of the PGM images normalized by max.
+func (pgm *PGM) Multiply(other *PGM, rescale bool) (*PGM, error) {
+	return pgm.combine(other, rescale, multiplyBy(pgm.max))
+}
+
+// Min returns the darkest of the two PGM images at every pixel.
+func (pgm *PGM) Min(other *PGM) (*PGM, error) {
+	return pgm.combine(other, false, math.Min)
+}
+
+// Max returns the lightest of the two PGM images at every pixel.
+func (pgm *PGM) Max(other *PGM) (*PGM, error) {
+	return pgm.combine(other, false, math.Max)
+}
+
+// Average returns the mean of the two PGM images.
+func (pgm *PGM) Average(other *PGM) (*PGM, error) {
+	return pgm.combine(other, false, average)
+}
+
+// Blend returns the weighted mean of the PGM images, weight going from 0 (only pgm) to 1
+// (only other).
+func (pgm *PGM) Blend(other *PGM, weight float64) (*PGM, error) {
+	return pgm.combine(other, false, blendBy(weight))
+}
+
+// Add returns the per-channel sum of the PPM images, see PGM.Add.
+func (ppm *PPM) Add(other *PPM, rescale bool) (*PPM, error) {
+	return ppm.combine(other, rescale, add)
+}
+
+// Subtract returns ppm minus other per channel, see PGM.Subtract.
+func (ppm *PPM) Subtract(other *PPM, rescale bool) (*PPM, error) {
+	return ppm.combine(other, rescale, subtract)
+}
+
+// AbsDiff returns the per-channel absolute difference of the PPM images.
+func (ppm *PPM) AbsDiff(other *PPM, rescale bool) (*PPM, error) {
+	return ppm.combine(other, rescale, absDiff)
+}
+
+// Multiply returns the per-channel product of the PPM images normalized by max.
+func (ppm *PPM) Multiply(other *PPM, rescale bool) (*PPM, error) {
+	return ppm.combine(other, rescale, multiplyBy(ppm.max))
+}
+
+// Min returns the per-channel minimum of the PPM images.
+func (ppm *PPM) Min(other *PPM) (*PPM, error) {
+	return ppm.combine(other, false, math.Min)
+}
+
+// Max returns the per-channel maximum of the PPM images.
+func (ppm *PPM) Max(other *PPM) (*PPM, error) {
+	return ppm.combine(other, false, math.Max)
+}
+
+// Average returns the per-channel mean of the PPM images.
+func (ppm *PPM) Average(other *PPM) (*PPM, error) {
+	return ppm.combine(other, false, average)
+}
+
+// Blend returns the weighted mean of the PPM images, weight going from 0 (only ppm) to 1
+// (only other).
+func (ppm *PPM) Blend(other *PPM, weight float64) (*PPM, error) {
+	return ppm.combine(other, false, blendBy(weight))
+}
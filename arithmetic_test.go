@@ -0,0 +1,53 @@
+package Netpbm
+
+import "testing"
+
+// TestPPMRescaleKeepsColor checks that rescaling uses one range for all the channels, so that
+// the colors keep their hue, and that results already within [0, max] are left unchanged.
+func TestPPMRescaleKeepsColor(t *testing.T) {
+	a := newPPM(2, 1, "P3", 255)
+	a.data[0] = []Pixel{{200, 100, 50}, {100, 90, 40}}
+	black := newPPM(2, 1, "P3", 255)
+
+	diff, err := a.Subtract(black, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for x, want := range a.data[0] {
+		if got := diff.data[0][x]; got != want {
+			t.Errorf("subtracting black: pixel %d = %v, want %v", x, got, want)
+		}
+	}
+
+	sum, err := a.Add(a, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Pixel{{255, 128, 64}, {128, 115, 51}} // Sums divided by 400/255.
+	for x := range want {
+		if got := sum.data[0][x]; got != want[x] {
+			t.Errorf("doubling: pixel %d = %v, want %v", x, got, want[x])
+		}
+	}
+}
+
+// TestPGMSubtractRescale checks that negative differences are shifted into range.
+func TestPGMSubtractRescale(t *testing.T) {
+	a, b := newPGM(2, 1, "P2", 100), newPGM(2, 1, "P2", 100)
+	a.data[0] = []uint8{0, 100}
+	b.data[0] = []uint8{100, 0}
+	out, err := a.Subtract(b, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.data[0][0] != 0 || out.data[0][1] != 100 {
+		t.Errorf("got %v, want [0 100]", out.data[0])
+	}
+}
+
+// TestArithmeticSizeMismatch checks that images of different sizes are rejected.
+func TestArithmeticSizeMismatch(t *testing.T) {
+	if _, err := newPGM(2, 2, "P2", 255).Add(newPGM(3, 2, "P2", 255), false); err == nil {
+		t.Error("expected an error for different sizes")
+	}
+}
@@ -0,0 +1,147 @@
+package Netpbm
+
+import "math"
+
+// gaussianKernel returns a normalized 1D Gaussian kernel covering three standard deviations.
+func gaussianKernel(sigma float64) []float64 {
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	for i := range kernel {
+		d := float64(i - radius)
+		kernel[i] = math.Exp(-d * d / (2 * sigma * sigma))
+	}
+	return normalizedVector(kernel)
+}
+
+// gaussianPlane returns the plane blurred by a Gaussian of standard deviation sigma.
+func gaussianPlane(plane [][]float64, sigma float64) [][]float64 {
+	kernel := gaussianKernel(sigma)
+	return convolvePlaneSeparable(plane, kernel, kernel, BorderClamp, 0)
+}
+
+// boxBlurPlane returns the plane averaged over (2*radius+1)^2 windows. Each pass keeps a
+// running sum that is updated by one pixel entering and one leaving the window, so the cost
+// doesn't depend on the radius.
+func boxBlurPlane(plane [][]float64, radius int) [][]float64 {
+	height := len(plane)
+	if height == 0 || radius <= 0 {
+		return plane
+	}
+	width := len(plane[0])
+	size := float64(2*radius + 1)
+
+	tmp := newPlane(width, height)
+	for y := 0; y < height; y++ {
+		sum := 0.0
+		for k := -radius; k <= radius; k++ {
+			sx, _ := borderIndex(k, width, BorderClamp)
+			sum += plane[y][sx]
+		} // Fill the window around the first pixel of the row.
+		for x := 0; x < width; x++ {
+			tmp[y][x] = sum / size
+			in, _ := borderIndex(x+radius+1, width, BorderClamp)
+			out, _ := borderIndex(x-radius, width, BorderClamp)
+			sum += plane[y][in] - plane[y][out] // Slide the window one pixel to the right.
+		}
+	} // Horizontal pass.
+
+	result := newPlane(width, height)
+	for x := 0; x < width; x++ {
+		sum := 0.0
+		for k := -radius; k <= radius; k++ {
+			sy, _ := borderIndex(k, height, BorderClamp)
+			sum += tmp[sy][x]
+		}
+		for y := 0; y < height; y++ {
+			result[y][x] = sum / size
+			in, _ := borderIndex(y+radius+1, height, BorderClamp)
+			out, _ := borderIndex(y-radius, height, BorderClamp)
+			sum += tmp[in][x] - tmp[out][x] // Slide the window one pixel down.
+		}
+	} // Vertical pass.
+	return result
+}
+
+// unsharpPlane returns the plane sharpened by adding back amount times the difference with
+// its Gaussian blur, wherever that difference is at least threshold.
+func unsharpPlane(plane [][]float64, sigma, amount, threshold float64) [][]float64 {
+	blurred := gaussianPlane(plane, sigma)
+	out := newPlane(len(plane[0]), len(plane))
+	for y := range plane {
+		for x := range plane[y] {
+			diff := plane[y][x] - blurred[y][x]
+			if math.Abs(diff) >= threshold {
+				out[y][x] = plane[y][x] + amount*diff
+			} else {
+				out[y][x] = plane[y][x]
+			} // Small differences are left alone so that noise in flat areas isn't amplified.
+		}
+	}
+	return out
+}
+
+// GaussianBlur blurs the PGM image with a Gaussian of standard deviation sigma.
+// Edge pixels are repeated past the borders. A sigma of zero or less does nothing.
+func (pgm *PGM) GaussianBlur(sigma float64) {
+	if sigma <= 0 || pgm.height == 0 {
+		return
+	}
+	pgm.setPlane(gaussianPlane(pgm.plane(), sigma))
+}
+
+// BoxBlur replaces each pixel of the PGM image with the mean of the (2*radius+1)^2 window
+// around it. It runs in constant time per pixel whatever the radius.
+func (pgm *PGM) BoxBlur(radius int) {
+	if radius <= 0 || pgm.height == 0 {
+		return
+	}
+	pgm.setPlane(boxBlurPlane(pgm.plane(), radius))
+}
+
+// UnsharpMask sharpens the PGM image. The details removed by a Gaussian blur of standard
+// deviation sigma are amplified by amount and added back, but only where they differ from the
+// original by at least threshold levels.
+func (pgm *PGM) UnsharpMask(sigma, amount float64, threshold uint8) {
+	if sigma <= 0 || pgm.height == 0 {
+		return
+	}
+	pgm.setPlane(unsharpPlane(pgm.plane(), sigma, amount, float64(threshold)))
+}
+
+// GaussianBlur blurs each channel of the PPM image with a Gaussian of standard deviation
+// sigma. Edge pixels are repeated past the borders. A sigma of zero or less does nothing.
+func (ppm *PPM) GaussianBlur(sigma float64) {
+	if sigma <= 0 || ppm.height == 0 {
+		return
+	}
+	planes := ppm.planes()
+	for c := range planes {
+		planes[c] = gaussianPlane(planes[c], sigma)
+	}
+	ppm.setPlanes(planes)
+}
+
+// BoxBlur replaces each channel of every pixel of the PPM image with its mean over the
+// (2*radius+1)^2 window around it.
+func (ppm *PPM) BoxBlur(radius int) {
+	if radius <= 0 || ppm.height == 0 {
+		return
+	}
+	planes := ppm.planes()
+	for c := range planes {
+		planes[c] = boxBlurPlane(planes[c], radius)
+	}
+	ppm.setPlanes(planes)
+}
+
+// UnsharpMask sharpens each channel of the PPM image, see PGM.UnsharpMask.
+func (ppm *PPM) UnsharpMask(sigma, amount float64, threshold uint8) {
+	if sigma <= 0 || ppm.height == 0 {
+		return
+	}
+	planes := ppm.planes()
+	for c := range planes {
+		planes[c] = unsharpPlane(planes[c], sigma, amount, float64(threshold))
+	}
+	ppm.setPlanes(planes)
+}
@@ -0,0 +1,90 @@
+package Netpbm
+
+import "fmt"
+
+// Channel identifies one of the color channels of a PPM image.
+type Channel int
+
+const (
+	ChannelRed Channel = iota
+	ChannelGreen
+	ChannelBlue
+)
+
+// get returns the value of the channel in the pixel.
+func (c Channel) get(p Pixel) uint8 {
+	switch c {
+	case ChannelGreen:
+		return p.G
+	case ChannelBlue:
+		return p.B
+	}
+	return p.R
+}
+
+// SplitChannels returns the red, green and blue channels of the PPM image as three PGM images
+// with the same max value. They are plain (P2) for a plain PPM and raw (P5) otherwise.
+func (ppm *PPM) SplitChannels() (r, g, b *PGM) {
+	magicNumber := "P5"
+	if ppm.magicNumber == "P3" {
+		magicNumber = "P2"
+	}
+	channels := ppm.channels()
+	pgms := [3]*PGM{}
+	for c := range pgms {
+		pgms[c] = &PGM{channels[c], ppm.width, ppm.height, magicNumber, ppm.max}
+	}
+	return pgms[0], pgms[1], pgms[2]
+}
+
+// MergeChannels assembles a PPM image from three PGM images used as its red, green and blue
+// channels. They must have the same size and max value. The PPM is plain (P3) if the red
+// channel is plain and raw (P6) otherwise.
+func MergeChannels(r, g, b *PGM) (*PPM, error) {
+	for _, c := range []*PGM{g, b} {
+		if c.width != r.width || c.height != r.height {
+			return nil, fmt.Errorf("channel sizes differ: %dx%d and %dx%d", r.width, r.height, c.width, c.height)
+		}
+		if c.max != r.max {
+			return nil, fmt.Errorf("channel max values differ: %d and %d", r.max, c.max)
+		}
+	}
+	magicNumber := "P6"
+	if r.magicNumber == "P2" {
+		magicNumber = "P3"
+	}
+	ppm := newPPM(r.width, r.height, magicNumber, r.max)
+	for y := 0; y < r.height; y++ {
+		for x := 0; x < r.width; x++ {
+			ppm.data[y][x] = Pixel{r.data[y][x], g.data[y][x], b.data[y][x]}
+		}
+	}
+	return ppm, nil
+}
+
+// Channel returns a single channel of the PPM image as a PGM image.
+func (ppm *PPM) Channel(c Channel) *PGM {
+	magicNumber := "P5"
+	if ppm.magicNumber == "P3" {
+		magicNumber = "P2"
+	}
+	pgm := newPGM(ppm.width, ppm.height, magicNumber, ppm.max)
+	for y := 0; y < ppm.height; y++ {
+		for x := 0; x < ppm.width; x++ {
+			pgm.data[y][x] = c.get(ppm.data[y][x])
+		}
+	}
+	return pgm
+}
+
+// Swizzle reorders the channels of the PPM image: the new red channel takes the values of the
+// channel r, the new green those of g and the new blue those of b. A channel may be used more
+// than once, Swizzle(ChannelBlue, ChannelGreen, ChannelRed) turns RGB into BGR.
+func (ppm *PPM) Swizzle(r, g, b Channel) {
+	for y := 0; y < ppm.height; y++ {
+		for x := 0; x < ppm.width; x++ {
+			p := ppm.data[y][x]
+			ppm.data[y][x] = Pixel{r.get(p), g.get(p), b.get(p)}
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package Netpbm
+
+import "math"
+
+// HSV is a color in the hue, saturation, value space. H is in degrees in [0, 360), S and V
+// are in [0, 1].
+type HSV struct {
+	H, S, V float64
+}
+
+// HSL is a color in the hue, saturation, lightness space. H is in degrees in [0, 360), S and L
+// are in [0, 1].
+type HSL struct {
+	H, S, L float64
+}
+
+// XYZ is a color in the CIE 1931 XYZ space relative to the D65 white point, with Y = 1 for white.
+type XYZ struct {
+	X, Y, Z float64
+}
+
+// Lab is a color in the CIELAB space relative to the D65 white point. L is in [0, 100], A and B
+// are roughly in [-128, 127].
+type Lab struct {
+	L, A, B float64
+}
+
+// YCbCr is a color in the full range YCbCr space of JPEG (BT.601). Y is in [0, 1], Cb and Cr
+// are in [-0.5, 0.5].
+type YCbCr struct {
+	Y, Cb, Cr float64
+}
+
+// D65 reference white used by the XYZ and Lab conversions.
+const (
+	whiteX = 0.95047
+	whiteY = 1.0
+	whiteZ = 1.08883
+)
+
+// normalized returns the channels of the pixel divided by max.
+func (p Pixel) normalized(max uint8) (float64, float64, float64) {
+	m := float64(max)
+	if m == 0 {
+		return 0, 0, 0
+	}
+	return float64(p.R) / m, float64(p.G) / m, float64(p.B) / m
+}
+
+// pixelFromNormalized builds a pixel from channels in [0, 1], clamping them to [0, max].
+func pixelFromNormalized(r, g, b float64, max uint8) Pixel {
+	m := float64(max)
+	return Pixel{clampToMax(r*m, max), clampToMax(g*m, max), clampToMax(b*m, max)}
+}
+
+// hue returns the hue in degrees of the normalized channels, given their maximum and chroma.
+func hue(r, g, b, maxC, chroma float64) float64 {
+	if chroma == 0 {
+		return 0
+	}
+	var h float64
+	switch maxC {
+	case r:
+		h = math.Mod((g-b)/chroma, 6)
+	case g:
+		h = (b-r)/chroma + 2
+	default:
+		h = (r-g)/chroma + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
+// rgbFromHueChroma returns the normalized channels for a hue, chroma and offset m added to all.
+func rgbFromHueChroma(h, chroma, m float64) (float64, float64, float64) {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	hp := h / 60
+	x := chroma * (1 - math.Abs(math.Mod(hp, 2)-1))
+	var r, g, b float64
+	switch {
+	case hp < 1:
+		r, g, b = chroma, x, 0
+	case hp < 2:
+		r, g, b = x, chroma, 0
+	case hp < 3:
+		r, g, b = 0, chroma, x
+	case hp < 4:
+		r, g, b = 0, x, chroma
+	case hp < 5:
+		r, g, b = x, 0, chroma
+	default:
+		r, g, b = chroma, 0, x
+	}
+	return r + m, g + m, b + m
+}
+
+// ToHSV converts the pixel, whose channels go up to max, to HSV.
+func (p Pixel) ToHSV(max uint8) HSV {
+	r, g, b := p.normalized(max)
+	maxC, minC := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	chroma := maxC - minC
+	s := 0.0
+	if maxC > 0 {
+		s = chroma / maxC
+	}
+	return HSV{hue(r, g, b, maxC, chroma), s, maxC}
+}
+
+// ToPixel converts the HSV color to a pixel whose channels go up to max.
+func (c HSV) ToPixel(max uint8) Pixel {
+	chroma := c.V * c.S
+	r, g, b := rgbFromHueChroma(c.H, chroma, c.V-chroma)
+	return pixelFromNormalized(r, g, b, max)
+}
+
+// ToHSL converts the pixel, whose channels go up to max, to HSL.
+func (p Pixel) ToHSL(max uint8) HSL {
+	r, g, b := p.normalized(max)
+	maxC, minC := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	chroma := maxC - minC
+	l := (maxC + minC) / 2
+	s := 0.0
+	if chroma > 0 {
+		s = chroma / (1 - math.Abs(2*l-1))
+	}
+	return HSL{hue(r, g, b, maxC, chroma), s, l}
+}
+
+// ToPixel converts the HSL color to a pixel whose channels go up to max.
+func (c HSL) ToPixel(max uint8) Pixel {
+	chroma := (1 - math.Abs(2*c.L-1)) * c.S
+	r, g, b := rgbFromHueChroma(c.H, chroma, c.L-chroma/2)
+	return pixelFromNormalized(r, g, b, max)
+}
+
+// srgbToLinear removes the sRGB transfer curve from a normalized channel.
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB applies the sRGB transfer curve to a linear channel.
+func linearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// ToXYZ converts the pixel, whose channels go up to max and are assumed to be sRGB, to XYZ.
+func (p Pixel) ToXYZ(max uint8) XYZ {
+	r, g, b := p.normalized(max)
+	r, g, b = srgbToLinear(r), srgbToLinear(g), srgbToLinear(b)
+	return XYZ{
+		X: 0.4124564*r + 0.3575761*g + 0.1804375*b,
+		Y: 0.2126729*r + 0.7151522*g + 0.0721750*b,
+		Z: 0.0193339*r + 0.1191920*g + 0.9503041*b,
+	}
+}
+
+// ToPixel converts the XYZ color to an sRGB pixel whose channels go up to max. Colors outside
+// of the sRGB gamut are clamped.
+func (c XYZ) ToPixel(max uint8) Pixel {
+	r := 3.2404542*c.X - 1.5371385*c.Y - 0.4985314*c.Z
+	g := -0.9692660*c.X + 1.8760108*c.Y + 0.0415560*c.Z
+	b := 0.0556434*c.X - 0.2040259*c.Y + 1.0572252*c.Z
+	return pixelFromNormalized(linearToSRGB(math.Max(r, 0)), linearToSRGB(math.Max(g, 0)), linearToSRGB(math.Max(b, 0)), max)
+}
+
+// ToLab converts the XYZ color to CIELAB.
+func (c XYZ) ToLab() Lab {
+	f := func(t float64) float64 {
+		if t > 216.0/24389 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27*t + 16) / 116
+	}
+	fx, fy, fz := f(c.X/whiteX), f(c.Y/whiteY), f(c.Z/whiteZ)
+	return Lab{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+}
+
+// ToXYZ converts the CIELAB color to XYZ.
+func (c Lab) ToXYZ() XYZ {
+	fy := (c.L + 16) / 116
+	fx, fz := fy+c.A/500, fy-c.B/200
+	inv := func(t float64) float64 {
+		if t*t*t > 216.0/24389 {
+			return t * t * t
+		}
+		return (116*t - 16) * 27 / 24389
+	}
+	return XYZ{whiteX * inv(fx), whiteY * inv(fy), whiteZ * inv(fz)}
+}
+
+// ToLab converts the pixel, whose channels go up to max and are assumed to be sRGB, to CIELAB.
+func (p Pixel) ToLab(max uint8) Lab {
+	return p.ToXYZ(max).ToLab()
+}
+
+// ToPixel converts the CIELAB color to an sRGB pixel whose channels go up to max.
+func (c Lab) ToPixel(max uint8) Pixel {
+	return c.ToXYZ().ToPixel(max)
+}
+
+// ToYCbCr converts the pixel, whose channels go up to max, to YCbCr.
+func (p Pixel) ToYCbCr(max uint8) YCbCr {
+	r, g, b := p.normalized(max)
+	return YCbCr{
+		Y:  0.299*r + 0.587*g + 0.114*b,
+		Cb: -0.168736*r - 0.331264*g + 0.5*b,
+		Cr: 0.5*r - 0.418688*g - 0.081312*b,
+	}
+}
+
+// ToPixel converts the YCbCr color to a pixel whose channels go up to max.
+func (c YCbCr) ToPixel(max uint8) Pixel {
+	r := c.Y + 1.402*c.Cr
+	g := c.Y - 0.344136*c.Cb - 0.714136*c.Cr
+	b := c.Y + 1.772*c.Cb
+	return pixelFromNormalized(r, g, b, max)
+}
+
+// mapHSL replaces every pixel of the PPM image with the result of f on its HSL color.
+func (ppm *PPM) mapHSL(f func(HSL) HSL) {
+	for y := 0; y < ppm.height; y++ {
+		for x := 0; x < ppm.width; x++ {
+			ppm.data[y][x] = f(ppm.data[y][x].ToHSL(ppm.max)).ToPixel(ppm.max)
+		}
+	}
+}
+
+// RotateHue shifts the hue of every pixel of the PPM image by the given number of degrees.
+func (ppm *PPM) RotateHue(degrees float64) {
+	ppm.mapHSL(func(c HSL) HSL {
+		c.H = math.Mod(c.H+degrees, 360)
+		if c.H < 0 {
+			c.H += 360
+		}
+		return c
+	})
+}
+
+// AdjustSaturation multiplies the saturation of every pixel of the PPM image by factor, 0
+// giving a grayscale image.
+func (ppm *PPM) AdjustSaturation(factor float64) {
+	ppm.mapHSL(func(c HSL) HSL {
+		c.S = math.Min(math.Max(c.S*factor, 0), 1)
+		return c
+	})
+}
+
+// AdjustLightness adds delta, from -1 to 1, to the lightness of every pixel of the PPM image.
+func (ppm *PPM) AdjustLightness(delta float64) {
+	ppm.mapHSL(func(c HSL) HSL {
+		c.L = math.Min(math.Max(c.L+delta, 0), 1)
+		return c
+	})
+}
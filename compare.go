@@ -0,0 +1,188 @@
+package Netpbm
+
+import (
+	"fmt"
+	"math"
+)
+
+// ChannelMetrics holds the similarity measures of one channel, or of all channels together.
+type ChannelMetrics struct {
+	MSE    float64 // Mean squared error, in squared levels.
+	PSNR   float64 // Peak signal to noise ratio in decibels, +Inf for identical images.
+	SSIM   float64 // Structural similarity, 1 for identical images.
+	MSSSIM float64 // Multi-scale structural similarity, 1 for identical images.
+}
+
+// Comparison holds the result of comparing two images. Channels has one entry for PGM images
+// and three (red, green, blue) for PPM images; Overall averages them.
+type Comparison struct {
+	Channels []ChannelMetrics
+	Overall  ChannelMetrics
+}
+
+// msssimWeights are the scale weights of Wang et al. for MS-SSIM.
+var msssimWeights = []float64{0.0448, 0.2856, 0.3001, 0.2363, 0.1333}
+
+// mse returns the mean squared error between two planes of the same size.
+func mse(a, b [][]float64) float64 {
+	sum, n := 0.0, 0
+	for y := range a {
+		for x := range a[y] {
+			d := a[y][x] - b[y][x]
+			sum += d * d
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// psnr converts a mean squared error to a peak signal to noise ratio for the max value.
+func psnr(mse float64, max uint8) float64 {
+	if mse == 0 {
+		return math.Inf(1)
+	}
+	return 10 * math.Log10(float64(max)*float64(max)/mse)
+}
+
+// ssimComponents returns the mean SSIM and the mean contrast-structure term of two planes, using
+// Gaussian weighted windows of standard deviation 1.5 as in the reference implementation.
+func ssimComponents(a, b [][]float64, max uint8) (float64, float64) {
+	c1 := math.Pow(0.01*float64(max), 2)
+	c2 := math.Pow(0.03*float64(max), 2)
+	product := func(p, q [][]float64) [][]float64 {
+		out := newPlane(len(p[0]), len(p))
+		for y := range p {
+			for x := range p[y] {
+				out[y][x] = p[y][x] * q[y][x]
+			}
+		}
+		return out
+	}
+	muA, muB := gaussianPlane(a, 1.5), gaussianPlane(b, 1.5)
+	aa, bb, ab := gaussianPlane(product(a, a), 1.5), gaussianPlane(product(b, b), 1.5), gaussianPlane(product(a, b), 1.5)
+
+	ssim, cs, n := 0.0, 0.0, 0
+	for y := range a {
+		for x := range a[y] {
+			ma, mb := muA[y][x], muB[y][x]
+			varA, varB, cov := aa[y][x]-ma*ma, bb[y][x]-mb*mb, ab[y][x]-ma*mb
+			contrast := (2*cov + c2) / (varA + varB + c2)
+			ssim += (2*ma*mb + c1) / (ma*ma + mb*mb + c1) * contrast
+			cs += contrast
+			n++
+		}
+	}
+	return ssim / float64(n), cs / float64(n)
+}
+
+// downsample halves the plane by averaging 2x2 blocks.
+func downsample(plane [][]float64) [][]float64 {
+	height, width := len(plane)/2, len(plane[0])/2
+	out := newPlane(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			out[y][x] = (plane[2*y][2*x] + plane[2*y][2*x+1] + plane[2*y+1][2*x] + plane[2*y+1][2*x+1]) / 4
+		}
+	}
+	return out
+}
+
+// msssim computes the multi-scale SSIM, using as many of the five scales as the plane size
+// allows (the weights of the scales used are renormalized).
+func msssim(a, b [][]float64, max uint8) float64 {
+	result, total := 1.0, 0.0
+	for scale, weight := range msssimWeights {
+		ssim, cs := ssimComponents(a, b, max)
+		total += weight
+		if scale == len(msssimWeights)-1 || len(a) < 2 || len(a[0]) < 2 {
+			result *= math.Pow(math.Max(ssim, 0), weight) // Only the coarsest scale uses the luminance term.
+			break
+		}
+		result *= math.Pow(math.Max(cs, 0), weight)
+		a, b = downsample(a), downsample(b)
+	}
+	return math.Pow(result, 1/total)
+}
+
+// compareChannels computes the metrics for each pair of planes and their average.
+func compareChannels(a, b [][][]float64, max uint8) Comparison {
+	var cmp Comparison
+	for c := range a {
+		m := ChannelMetrics{MSE: mse(a[c], b[c])}
+		m.PSNR = psnr(m.MSE, max)
+		if len(a[c]) > 0 && len(a[c][0]) > 0 {
+			m.SSIM, _ = ssimComponents(a[c], b[c], max)
+			m.MSSSIM = msssim(a[c], b[c], max)
+		}
+		cmp.Channels = append(cmp.Channels, m)
+		cmp.Overall.MSE += m.MSE / float64(len(a))
+		cmp.Overall.SSIM += m.SSIM / float64(len(a))
+		cmp.Overall.MSSSIM += m.MSSSIM / float64(len(a))
+	}
+	cmp.Overall.PSNR = psnr(cmp.Overall.MSE, max)
+	return cmp
+}
+
+// Compare measures how close the PGM image is to other, which must have the same size and
+// max value.
+func (pgm *PGM) Compare(other *PGM) (Comparison, error) {
+	if pgm.width != other.width || pgm.height != other.height || pgm.max != other.max {
+		return Comparison{}, fmt.Errorf("images differ in size or max value: %dx%d/%d and %dx%d/%d", pgm.width, pgm.height, pgm.max, other.width, other.height, other.max)
+	}
+	return compareChannels([][][]float64{pgm.plane()}, [][][]float64{other.plane()}, pgm.max), nil
+}
+
+// Compare measures how close the PPM image is to other, channel by channel. Both images must
+// have the same size and max value.
+func (ppm *PPM) Compare(other *PPM) (Comparison, error) {
+	if ppm.width != other.width || ppm.height != other.height || ppm.max != other.max {
+		return Comparison{}, fmt.Errorf("images differ in size or max value: %dx%d/%d and %dx%d/%d", ppm.width, ppm.height, ppm.max, other.width, other.height, other.max)
+	}
+	pa, pb := ppm.planes(), other.planes()
+	return compareChannels(pa[:], pb[:], ppm.max), nil
+}
+
+// Diff returns a copy of the PPM image dimmed to a third of its brightness, where the pixels
+// differing from other by more than threshold on any channel are painted with highlight.
+func (ppm *PPM) Diff(other *PPM, threshold uint8, highlight Pixel) (*PPM, error) {
+	if ppm.width != other.width || ppm.height != other.height {
+		return nil, fmt.Errorf("image sizes differ: %dx%d and %dx%d", ppm.width, ppm.height, other.width, other.height)
+	}
+	out := newPPM(ppm.width, ppm.height, ppm.magicNumber, ppm.max)
+	for y := 0; y < ppm.height; y++ {
+		for x := 0; x < ppm.width; x++ {
+			pa, pb := ppm.data[y][x], other.data[y][x]
+			if !withinTolerance(pa.R, pb.R, threshold) || !withinTolerance(pa.G, pb.G, threshold) || !withinTolerance(pa.B, pb.B, threshold) {
+				out.data[y][x] = highlight
+			} else {
+				out.data[y][x] = Pixel{pa.R / 3, pa.G / 3, pa.B / 3} // Dim the unchanged pixels so the differences stand out.
+			}
+		}
+	}
+	return out, nil
+}
+
+// Diff returns a PPM image showing the PGM image in dimmed gray, with the pixels differing
+// from other by more than threshold painted with highlight.
+func (pgm *PGM) Diff(other *PGM, threshold uint8, highlight Pixel) (*PPM, error) {
+	return pgm.toPPM().Diff(other.toPPM(), threshold, highlight)
+}
+
+// toPPM returns the PGM image as a gray PPM image.
+func (pgm *PGM) toPPM() *PPM {
+	magicNumber := "P6"
+	if pgm.magicNumber == "P2" {
+		magicNumber = "P3"
+	}
+	ppm := newPPM(pgm.width, pgm.height, magicNumber, pgm.max)
+	for y := 0; y < pgm.height; y++ {
+		for x := 0; x < pgm.width; x++ {
+			v := pgm.data[y][x]
+			ppm.data[y][x] = Pixel{v, v, v}
+		}
+	}
+	return ppm
+}
@@ -0,0 +1,103 @@
+package Netpbm
+
+// Connectivity selects which neighbors of a pixel are considered adjacent to it.
+type Connectivity int
+
+const (
+	Connectivity4 Connectivity = 4 // Only the horizontal and vertical neighbors.
+	Connectivity8 Connectivity = 8 // The diagonal neighbors as well.
+)
+
+// offsets returns the neighbor offsets for the connectivity.
+func (c Connectivity) offsets() []Point {
+	if c == Connectivity8 {
+		return []Point{{1, 0}, {-1, 0}, {0, 1}, {0, -1}, {1, 1}, {-1, 1}, {1, -1}, {-1, -1}}
+	}
+	return []Point{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+}
+
+// Component describes a connected group of true pixels of a PBM image.
+type Component struct {
+	Label                int     // Label of the component in the label map, starting at 1.
+	Area                 int     // Number of pixels.
+	Bounds               Rect    // Smallest rectangle containing the component.
+	CentroidX, CentroidY float64 // Mean position of the pixels.
+	Perimeter            int     // Number of pixel sides shared with a false pixel or the image edge.
+}
+
+// Label finds the connected groups of true pixels of the PBM image. It returns a label map
+// with the same size as the image, where false pixels are 0 and the pixels of each component
+// hold its label, together with the statistics of every component ordered by label.
+func (pbm *PBM) Label(connectivity Connectivity) ([][]int, []Component) {
+	labels := make([][]int, pbm.height)
+	for y := range labels {
+		labels[y] = make([]int, pbm.width)
+	}
+	var components []Component
+	var stack []Point
+	neighbors := connectivity.offsets()
+
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			if !pbm.data[y][x] || labels[y][x] != 0 {
+				continue
+			}
+			c := Component{Label: len(components) + 1}
+			minX, minY, maxX, maxY := x, y, x, y
+			sumX, sumY := 0, 0
+			labels[y][x] = c.Label
+			stack = append(stack[:0], Point{x, y})
+			for len(stack) > 0 { // Explicit stack instead of recursion so that large blobs don't overflow.
+				p := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				c.Area++
+				sumX += p.X
+				sumY += p.Y
+				minX, minY = min(minX, p.X), min(minY, p.Y)
+				maxX, maxY = max(maxX, p.X), max(maxY, p.Y)
+				for _, d := range []Point{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+					if !pbm.At(p.X+d.X, p.Y+d.Y) {
+						c.Perimeter++
+					}
+				} // Count the sides of the pixel facing the background.
+				for _, d := range neighbors {
+					nx, ny := p.X+d.X, p.Y+d.Y
+					if nx >= 0 && ny >= 0 && nx < pbm.width && ny < pbm.height && pbm.data[ny][nx] && labels[ny][nx] == 0 {
+						labels[ny][nx] = c.Label
+						stack = append(stack, Point{nx, ny})
+					}
+				}
+			}
+			c.Bounds = Rect{minX, minY, maxX - minX + 1, maxY - minY + 1}
+			c.CentroidX = float64(sumX) / float64(c.Area)
+			c.CentroidY = float64(sumY) / float64(c.Area)
+			components = append(components, c)
+		}
+	}
+	return labels, components
+}
+
+// FilterComponents returns a new PBM image keeping only the components for which keep
+// returns true.
+func (pbm *PBM) FilterComponents(connectivity Connectivity, keep func(Component) bool) *PBM {
+	labels, components := pbm.Label(connectivity)
+	kept := make([]bool, len(components)+1)
+	for _, c := range components {
+		kept[c.Label] = keep(c)
+	}
+	out := newPBM(pbm.width, pbm.height, pbm.magicNumber)
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			out.data[y][x] = kept[labels[y][x]]
+		}
+	}
+	return out
+}
+
+// RemoveSmallComponents returns a new PBM image without the components of less than
+// minArea pixels, which cleans speckles out of a mask.
+func (pbm *PBM) RemoveSmallComponents(minArea int, connectivity Connectivity) *PBM {
+	return pbm.FilterComponents(connectivity, func(c Component) bool {
+		return c.Area >= minArea
+	})
+}
@@ -0,0 +1,132 @@
+package Netpbm
+
+import (
+	"fmt"
+	"math"
+)
+
+// CompositeOperator is a Porter-Duff operator deciding how much of the source and of the
+// destination show through in the result.
+type CompositeOperator int
+
+const (
+	OpSrcOver CompositeOperator = iota // Source over destination, the usual overlay.
+	OpClear                            // Neither source nor destination.
+	OpSrc                              // Source only.
+	OpDst                              // Destination only.
+	OpDstOver                          // Destination over source.
+	OpSrcIn                            // Source where the destination is.
+	OpDstIn                            // Destination where the source is.
+	OpSrcOut                           // Source where the destination isn't.
+	OpDstOut                           // Destination where the source isn't.
+	OpSrcAtop                          // Source over destination, only where the destination is.
+	OpDstAtop                          // Destination over source, only where the source is.
+	OpXor                              // Source and destination where the other isn't.
+)
+
+// BlendMode selects how the source color is mixed with the destination color where both are
+// present.
+type BlendMode int
+
+const (
+	BlendNormal     BlendMode = iota // The source color replaces the destination color.
+	BlendMultiply                    // Product of the colors, always darker.
+	BlendScreen                      // Inverse of the product of the inverses, always lighter.
+	BlendOverlay                     // Multiply on dark destinations, screen on light ones.
+	BlendDarken                      // Darkest of the two colors.
+	BlendLighten                     // Lightest of the two colors.
+	BlendDifference                  // Absolute difference of the colors.
+	BlendAdd                         // Sum of the colors, saturating at white.
+)
+
+// CompositeOptions controls how Composite combines two images.
+type CompositeOptions struct {
+	Operator CompositeOperator // Porter-Duff operator, OpSrcOver when zero.
+	Blend    BlendMode         // Blend mode, BlendNormal when zero.
+	Mask     *PGM              // Optional alpha of the source, same size as the source. Nil means opaque.
+}
+
+// blend mixes normalized destination and source channels with the blend mode.
+func (mode BlendMode) blend(d, s float64) float64 {
+	switch mode {
+	case BlendMultiply:
+		return d * s
+	case BlendScreen:
+		return d + s - d*s
+	case BlendOverlay:
+		if d < 0.5 {
+			return 2 * d * s
+		}
+		return 1 - 2*(1-d)*(1-s)
+	case BlendDarken:
+		return math.Min(d, s)
+	case BlendLighten:
+		return math.Max(d, s)
+	case BlendDifference:
+		return math.Abs(d - s)
+	case BlendAdd:
+		return math.Min(d+s, 1)
+	}
+	return s
+}
+
+// factors returns the Porter-Duff fractions of the source and of the destination kept by
+// the operator, given their alphas.
+func (op CompositeOperator) factors(as, ad float64) (float64, float64) {
+	switch op {
+	case OpClear:
+		return 0, 0
+	case OpSrc:
+		return 1, 0
+	case OpDst:
+		return 0, 1
+	case OpDstOver:
+		return 1 - ad, 1
+	case OpSrcIn:
+		return ad, 0
+	case OpDstIn:
+		return 0, as
+	case OpSrcOut:
+		return 1 - ad, 0
+	case OpDstOut:
+		return 0, 1 - as
+	case OpSrcAtop:
+		return ad, 1 - as
+	case OpDstAtop:
+		return 1 - ad, as
+	case OpXor:
+		return 1 - ad, 1 - as
+	}
+	return 1, 1 - as
+}
+
+// Composite draws src onto dst with its top-left corner at the given point. Source alpha comes
+// from opts.Mask, the destination is opaque. Where the result isn't fully opaque, for example
+// with OpSrcIn and a partial mask, it is flattened onto black since PPM images have no alpha.
+// Values are normalized with the max value of each image, so images with different max values
+// can be combined. Only the pixels covered by src are modified.
+func Composite(dst, src *PPM, at Point, opts CompositeOptions) error {
+	if opts.Mask != nil && (opts.Mask.width != src.width || opts.Mask.height != src.height) {
+		return fmt.Errorf("mask is %dx%d but source is %dx%d", opts.Mask.width, opts.Mask.height, src.width, src.height)
+	}
+	area := Rect{at.X, at.Y, src.width, src.height}.clip(dst.width, dst.height)
+	const ad = 1.0 // The destination is opaque.
+	for y := area.Y; y < area.Y+area.Height; y++ {
+		for x := area.X; x < area.X+area.Width; x++ {
+			sx, sy := x-at.X, y-at.Y
+			as := 1.0
+			if opts.Mask != nil && opts.Mask.max > 0 {
+				as = float64(opts.Mask.data[sy][sx]) / float64(opts.Mask.max)
+			}
+			fa, fb := opts.Operator.factors(as, ad)
+			sr, sg, sb := src.data[sy][sx].normalized(src.max)
+			dr, dg, db := dst.data[y][x].normalized(dst.max)
+			mix := func(d, s float64) float64 {
+				s = (1-ad)*s + ad*opts.Blend.blend(d, s) // Blend only where the destination is present.
+				return as*fa*s + ad*fb*d
+			}
+			dst.data[y][x] = pixelFromNormalized(mix(dr, sr), mix(dg, sg), mix(db, sb), dst.max)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package Netpbm
+
+import "fmt"
+
+// Kernel is a 2D convolution kernel stored row by row. Both of its dimensions must be odd
+// so that the kernel has a center pixel.
+type Kernel [][]float64
+
+// ConvolveOptions controls how a convolution is computed.
+type ConvolveOptions struct {
+	Border    BorderMode // How pixels outside of the image are synthesized.
+	Fill      float64    // Value of the pixels outside of the image when Border is BorderConstant.
+	Normalize bool       // Divide the kernel by the sum of its weights when that sum isn't zero.
+	Bias      float64    // Value added to every result before it is clamped to [0, max].
+}
+
+// validate checks that the kernel is rectangular and has odd dimensions.
+func (k Kernel) validate() error {
+	if len(k) == 0 || len(k)%2 == 0 {
+		return fmt.Errorf("kernel height must be odd, got %d", len(k))
+	}
+	width := len(k[0])
+	if width%2 == 0 {
+		return fmt.Errorf("kernel width must be odd, got %d", width)
+	}
+	for i, row := range k {
+		if len(row) != width {
+			return fmt.Errorf("kernel row %d has %d values, expected %d", i, len(row), width)
+		}
+	}
+	return nil
+}
+
+// normalized returns a copy of the kernel whose weights sum to 1, or the kernel itself
+// when its weights sum to zero (for example a derivative kernel).
+func (k Kernel) normalized() Kernel {
+	sum := 0.0
+	for _, row := range k {
+		for _, v := range row {
+			sum += v
+		}
+	}
+	if sum == 0 {
+		return k
+	}
+	out := make(Kernel, len(k))
+	for i, row := range k {
+		out[i] = make([]float64, len(row))
+		for j, v := range row {
+			out[i][j] = v / sum
+		}
+	}
+	return out
+}
+
+// normalizedVector does the same as Kernel.normalized for a 1D kernel.
+func normalizedVector(v []float64) []float64 {
+	sum := 0.0
+	for _, w := range v {
+		sum += w
+	}
+	if sum == 0 {
+		return v
+	}
+	out := make([]float64, len(v))
+	for i, w := range v {
+		out[i] = w / sum
+	}
+	return out
+}
+
+// newPlane allocates a width x height plane of float samples.
+func newPlane(width, height int) [][]float64 {
+	plane := make([][]float64, height)
+	for y := range plane {
+		plane[y] = make([]float64, width)
+	}
+	return plane
+}
+
+// plane returns the pixel values of the PGM image as floats.
+func (pgm *PGM) plane() [][]float64 {
+	plane := newPlane(pgm.width, pgm.height)
+	for y := 0; y < pgm.height; y++ {
+		for x := 0; x < pgm.width; x++ {
+			plane[y][x] = float64(pgm.data[y][x])
+		}
+	}
+	return plane
+}
+
+// setPlane stores the plane into the PGM image, rounding and clamping each value to [0, max].
+func (pgm *PGM) setPlane(plane [][]float64) {
+	for y := 0; y < pgm.height; y++ {
+		for x := 0; x < pgm.width; x++ {
+			pgm.data[y][x] = clampToMax(plane[y][x], pgm.max)
+		}
+	}
+}
+
+// planes returns the red, green and blue channels of the PPM image as float planes.
+func (ppm *PPM) planes() [3][][]float64 {
+	var planes [3][][]float64
+	for c := range planes {
+		planes[c] = newPlane(ppm.width, ppm.height)
+	}
+	for y := 0; y < ppm.height; y++ {
+		for x := 0; x < ppm.width; x++ {
+			p := ppm.data[y][x]
+			planes[0][y][x] = float64(p.R)
+			planes[1][y][x] = float64(p.G)
+			planes[2][y][x] = float64(p.B)
+		}
+	}
+	return planes
+}
+
+// setPlanes stores the three channel planes into the PPM image, rounding and clamping
+// each value to [0, max].
+func (ppm *PPM) setPlanes(planes [3][][]float64) {
+	for y := 0; y < ppm.height; y++ {
+		for x := 0; x < ppm.width; x++ {
+			ppm.data[y][x] = Pixel{
+				R: clampToMax(planes[0][y][x], ppm.max),
+				G: clampToMax(planes[1][y][x], ppm.max),
+				B: clampToMax(planes[2][y][x], ppm.max),
+			}
+		}
+	}
+}
+
+// planeAt returns the sample at (x, y), synthesizing pixels outside of the plane
+// according to the border mode.
+func planeAt(plane [][]float64, x, y int, border BorderMode, fill float64) float64 {
+	sy, okY := borderIndex(y, len(plane), border)
+	if !okY {
+		return fill
+	}
+	sx, okX := borderIndex(x, len(plane[sy]), border)
+	if !okX {
+		return fill
+	}
+	return plane[sy][sx]
+}
+
+// convolvePlane convolves the plane with the kernel and returns a new plane. The kernel
+// must have been validated. No bias or clamping is applied.
+func convolvePlane(plane [][]float64, k Kernel, border BorderMode, fill float64) [][]float64 {
+	height := len(plane)
+	if height == 0 {
+		return plane
+	}
+	width := len(plane[0])
+	ry, rx := len(k)/2, len(k[0])/2
+	out := newPlane(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sum := 0.0
+			for ky := -ry; ky <= ry; ky++ {
+				for kx := -rx; kx <= rx; kx++ {
+					sum += k[ry-ky][rx-kx] * planeAt(plane, x+kx, y+ky, border, fill)
+				}
+			} // The kernel is mirrored, which makes this a true convolution rather than a correlation.
+			out[y][x] = sum
+		}
+	}
+	return out
+}
+
+// convolvePlaneSeparable convolves the plane with the horizontal kernel and then with the
+// vertical kernel. Both kernels must have an odd length.
+func convolvePlaneSeparable(plane [][]float64, horizontal, vertical []float64, border BorderMode, fill float64) [][]float64 {
+	height := len(plane)
+	if height == 0 {
+		return plane
+	}
+	width := len(plane[0])
+	rx, ry := len(horizontal)/2, len(vertical)/2
+
+	tmp := newPlane(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sum := 0.0
+			for k := -rx; k <= rx; k++ {
+				sum += horizontal[rx-k] * planeAt(plane, x+k, y, border, fill)
+			}
+			tmp[y][x] = sum
+		}
+	} // Horizontal pass.
+
+	fillRow := fill * sumOf(horizontal) // A constant border row went through the horizontal pass too.
+	out := newPlane(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sum := 0.0
+			for k := -ry; k <= ry; k++ {
+				sy, ok := borderIndex(y+k, height, border)
+				if ok {
+					sum += vertical[ry-k] * tmp[sy][x]
+				} else {
+					sum += vertical[ry-k] * fillRow
+				}
+			}
+			out[y][x] = sum
+		}
+	} // Vertical pass.
+	return out
+}
+
+// sumOf returns the sum of the values.
+func sumOf(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum
+}
+
+// addBias adds the bias to every sample of the plane.
+func addBias(plane [][]float64, bias float64) {
+	if bias == 0 {
+		return
+	}
+	for y := range plane {
+		for x := range plane[y] {
+			plane[y][x] += bias
+		}
+	}
+}
+
+// prepareSeparable validates and optionally normalizes a pair of 1D kernels.
+func prepareSeparable(horizontal, vertical []float64, normalize bool) ([]float64, []float64, error) {
+	if len(horizontal)%2 == 0 || len(vertical)%2 == 0 {
+		return nil, nil, fmt.Errorf("separable kernels must have odd lengths, got %d and %d", len(horizontal), len(vertical))
+	}
+	if normalize {
+		horizontal, vertical = normalizedVector(horizontal), normalizedVector(vertical)
+	}
+	return horizontal, vertical, nil
+}
+
+// Convolve convolves the PGM image with the kernel. Results are clamped to [0, max].
+func (pgm *PGM) Convolve(k Kernel, opts ConvolveOptions) error {
+	if err := k.validate(); err != nil {
+		return err
+	}
+	if opts.Normalize {
+		k = k.normalized()
+	}
+	out := convolvePlane(pgm.plane(), k, opts.Border, opts.Fill)
+	addBias(out, opts.Bias)
+	pgm.setPlane(out)
+	return nil
+}
+
+// ConvolveSeparable convolves the PGM image with the separable kernel formed by the
+// horizontal and vertical 1D kernels, which is much faster than Convolve for large kernels.
+func (pgm *PGM) ConvolveSeparable(horizontal, vertical []float64, opts ConvolveOptions) error {
+	horizontal, vertical, err := prepareSeparable(horizontal, vertical, opts.Normalize)
+	if err != nil {
+		return err
+	}
+	out := convolvePlaneSeparable(pgm.plane(), horizontal, vertical, opts.Border, opts.Fill)
+	addBias(out, opts.Bias)
+	pgm.setPlane(out)
+	return nil
+}
+
+// Convolve convolves each channel of the PPM image with the kernel. Results are clamped to [0, max].
+func (ppm *PPM) Convolve(k Kernel, opts ConvolveOptions) error {
+	if err := k.validate(); err != nil {
+		return err
+	}
+	if opts.Normalize {
+		k = k.normalized()
+	}
+	planes := ppm.planes()
+	for c := range planes {
+		planes[c] = convolvePlane(planes[c], k, opts.Border, opts.Fill)
+		addBias(planes[c], opts.Bias)
+	}
+	ppm.setPlanes(planes)
+	return nil
+}
+
+// ConvolveSeparable convolves each channel of the PPM image with the separable kernel formed
+// by the horizontal and vertical 1D kernels.
+func (ppm *PPM) ConvolveSeparable(horizontal, vertical []float64, opts ConvolveOptions) error {
+	horizontal, vertical, err := prepareSeparable(horizontal, vertical, opts.Normalize)
+	if err != nil {
+		return err
+	}
+	planes := ppm.planes()
+	for c := range planes {
+		planes[c] = convolvePlaneSeparable(planes[c], horizontal, vertical, opts.Border, opts.Fill)
+		addBias(planes[c], opts.Bias)
+	}
+	ppm.setPlanes(planes)
+	return nil
+}
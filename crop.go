@@ -0,0 +1,211 @@
+package Netpbm
+
+// Rect represents a rectangular region whose top-left corner is at (X, Y).
+type Rect struct {
+	X, Y, Width, Height int
+}
+
+// BorderMode selects how pixels outside of the image are synthesized.
+type BorderMode int
+
+const (
+	BorderConstant BorderMode = iota // Use a fixed fill value.
+	BorderClamp                      // Repeat the nearest edge pixel (edge-replicate).
+	BorderMirror                     // Reflect the image at its edges, the edge pixel is repeated once.
+	BorderWrap                       // Tile the image, pixels past one edge come from the opposite edge.
+)
+
+// clip returns the part of the rectangle that lies inside a width x height image.
+func (r Rect) clip(width, height int) Rect {
+	x0, y0 := max(r.X, 0), max(r.Y, 0)
+	x1, y1 := min(r.X+r.Width, width), min(r.Y+r.Height, height)
+	if x1 <= x0 || y1 <= y0 {
+		return Rect{x0, y0, 0, 0}
+	} // An empty intersection gives an empty rectangle.
+	return Rect{x0, y0, x1 - x0, y1 - y0}
+}
+
+// borderIndex maps a possibly out of range index onto [0, n) according to the border mode.
+// The boolean is false when the index falls outside and the mode is BorderConstant.
+func borderIndex(i, n int, mode BorderMode) (int, bool) {
+	if i >= 0 && i < n {
+		return i, true
+	}
+	if n <= 0 {
+		return 0, false
+	}
+	switch mode {
+	case BorderClamp:
+		return min(max(i, 0), n-1), true
+	case BorderWrap:
+		i %= n
+		if i < 0 {
+			i += n
+		}
+		return i, true
+	case BorderMirror:
+		period := 2 * n
+		i %= period
+		if i < 0 {
+			i += period
+		}
+		if i >= n {
+			i = period - 1 - i
+		} // The second half of the period walks back over the image.
+		return i, true
+	}
+	return 0, false
+}
+
+// Crop returns a new PBM image containing the pixels inside rect.
+func (pbm *PBM) Crop(rect Rect) *PBM {
+	r := rect.clip(pbm.width, pbm.height)
+	out := newPBM(r.Width, r.Height, pbm.magicNumber)
+	for y := 0; y < r.Height; y++ {
+		copy(out.data[y], pbm.data[r.Y+y][r.X:r.X+r.Width])
+	} // Copy each row of the region so that the new image has its own storage.
+	return out
+}
+
+// SubImage returns a PBM view of the pixels inside rect that shares storage with pbm.
+// Set and the other in-place pixel operations on the view modify the parent image,
+// while operations that reorder or reallocate rows (Flop) only affect the view.
+func (pbm *PBM) SubImage(rect Rect) *PBM {
+	r := rect.clip(pbm.width, pbm.height)
+	data := make([][]bool, r.Height)
+	for y := range data {
+		data[y] = pbm.data[r.Y+y][r.X : r.X+r.Width : r.X+r.Width]
+	} // Slice each parent row, capping the capacity so the view cannot grow into the parent.
+	return &PBM{data, r.Width, r.Height, pbm.magicNumber}
+}
+
+// Pad returns a new PBM image surrounded by borders of the given sizes filled with fill.
+// Negative sizes are treated as zero.
+func (pbm *PBM) Pad(top, right, bottom, left int, fill bool) *PBM {
+	return pbm.extend(top, right, bottom, left, BorderConstant, fill)
+}
+
+// Extend returns a new PBM image surrounded by borders of the given sizes whose pixels
+// are synthesized according to mode. BorderConstant fills the borders with false.
+func (pbm *PBM) Extend(top, right, bottom, left int, mode BorderMode) *PBM {
+	return pbm.extend(top, right, bottom, left, mode, false)
+}
+
+func (pbm *PBM) extend(top, right, bottom, left int, mode BorderMode, fill bool) *PBM {
+	top, right, bottom, left = max(top, 0), max(right, 0), max(bottom, 0), max(left, 0)
+	out := newPBM(pbm.width+left+right, pbm.height+top+bottom, pbm.magicNumber)
+	for y := 0; y < out.height; y++ {
+		sy, okY := borderIndex(y-top, pbm.height, mode)
+		for x := 0; x < out.width; x++ {
+			sx, okX := borderIndex(x-left, pbm.width, mode)
+			if okX && okY {
+				out.data[y][x] = pbm.data[sy][sx]
+			} else {
+				out.data[y][x] = fill
+			} // Pixels that map back into the source are copied, the others get the fill value.
+		}
+	}
+	return out
+}
+
+// Crop returns a new PGM image containing the pixels inside rect.
+func (pgm *PGM) Crop(rect Rect) *PGM {
+	r := rect.clip(pgm.width, pgm.height)
+	out := newPGM(r.Width, r.Height, pgm.magicNumber, pgm.max)
+	for y := 0; y < r.Height; y++ {
+		copy(out.data[y], pgm.data[r.Y+y][r.X:r.X+r.Width])
+	} // Copy each row of the region so that the new image has its own storage.
+	return out
+}
+
+// SubImage returns a PGM view of the pixels inside rect that shares storage with pgm.
+// Set and the other in-place pixel operations on the view modify the parent image,
+// while operations that reorder or reallocate rows (Flop, Rotate90CW) only affect the view.
+func (pgm *PGM) SubImage(rect Rect) *PGM {
+	r := rect.clip(pgm.width, pgm.height)
+	data := make([][]uint8, r.Height)
+	for y := range data {
+		data[y] = pgm.data[r.Y+y][r.X : r.X+r.Width : r.X+r.Width]
+	} // Slice each parent row, capping the capacity so the view cannot grow into the parent.
+	return &PGM{data, r.Width, r.Height, pgm.magicNumber, pgm.max}
+}
+
+// Pad returns a new PGM image surrounded by borders of the given sizes filled with fill.
+// Negative sizes are treated as zero.
+func (pgm *PGM) Pad(top, right, bottom, left int, fill uint8) *PGM {
+	return pgm.extend(top, right, bottom, left, BorderConstant, fill)
+}
+
+// Extend returns a new PGM image surrounded by borders of the given sizes whose pixels
+// are synthesized according to mode. BorderConstant fills the borders with 0.
+func (pgm *PGM) Extend(top, right, bottom, left int, mode BorderMode) *PGM {
+	return pgm.extend(top, right, bottom, left, mode, 0)
+}
+
+func (pgm *PGM) extend(top, right, bottom, left int, mode BorderMode, fill uint8) *PGM {
+	top, right, bottom, left = max(top, 0), max(right, 0), max(bottom, 0), max(left, 0)
+	out := newPGM(pgm.width+left+right, pgm.height+top+bottom, pgm.magicNumber, pgm.max)
+	for y := 0; y < out.height; y++ {
+		sy, okY := borderIndex(y-top, pgm.height, mode)
+		for x := 0; x < out.width; x++ {
+			sx, okX := borderIndex(x-left, pgm.width, mode)
+			if okX && okY {
+				out.data[y][x] = pgm.data[sy][sx]
+			} else {
+				out.data[y][x] = fill
+			} // Pixels that map back into the source are copied, the others get the fill value.
+		}
+	}
+	return out
+}
+
+// Crop returns a new PPM image containing the pixels inside rect.
+func (ppm *PPM) Crop(rect Rect) *PPM {
+	r := rect.clip(ppm.width, ppm.height)
+	out := newPPM(r.Width, r.Height, ppm.magicNumber, ppm.max)
+	for y := 0; y < r.Height; y++ {
+		copy(out.data[y], ppm.data[r.Y+y][r.X:r.X+r.Width])
+	} // Copy each row of the region so that the new image has its own storage.
+	return out
+}
+
+// SubImage returns a PPM view of the pixels inside rect that shares storage with ppm.
+// Set and the Draw* methods on the view modify the parent image, while operations
+// that reorder or reallocate rows (Flop, Rotate90CW) only affect the view.
+func (ppm *PPM) SubImage(rect Rect) *PPM {
+	r := rect.clip(ppm.width, ppm.height)
+	data := make([][]Pixel, r.Height)
+	for y := range data {
+		data[y] = ppm.data[r.Y+y][r.X : r.X+r.Width : r.X+r.Width]
+	} // Slice each parent row, capping the capacity so the view cannot grow into the parent.
+	return &PPM{data, r.Width, r.Height, ppm.magicNumber, ppm.max}
+}
+
+// Pad returns a new PPM image surrounded by borders of the given sizes filled with fill.
+// Negative sizes are treated as zero.
+func (ppm *PPM) Pad(top, right, bottom, left int, fill Pixel) *PPM {
+	return ppm.extend(top, right, bottom, left, BorderConstant, fill)
+}
+
+// Extend returns a new PPM image surrounded by borders of the given sizes whose pixels
+// are synthesized according to mode. BorderConstant fills the borders with black.
+func (ppm *PPM) Extend(top, right, bottom, left int, mode BorderMode) *PPM {
+	return ppm.extend(top, right, bottom, left, mode, Pixel{})
+}
+
+func (ppm *PPM) extend(top, right, bottom, left int, mode BorderMode, fill Pixel) *PPM {
+	top, right, bottom, left = max(top, 0), max(right, 0), max(bottom, 0), max(left, 0)
+	out := newPPM(ppm.width+left+right, ppm.height+top+bottom, ppm.magicNumber, ppm.max)
+	for y := 0; y < out.height; y++ {
+		sy, okY := borderIndex(y-top, ppm.height, mode)
+		for x := 0; x < out.width; x++ {
+			sx, okX := borderIndex(x-left, ppm.width, mode)
+			if okX && okY {
+				out.data[y][x] = ppm.data[sy][sx]
+			} else {
+				out.data[y][x] = fill
+			} // Pixels that map back into the source are copied, the others get the fill value.
+		}
+	}
+	return out
+}
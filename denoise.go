@@ -0,0 +1,174 @@
+package Netpbm
+
+import "math"
+
+// BilateralOptions configures a bilateral filter. Zero fields fall back to their defaults.
+type BilateralOptions struct {
+	SpatialSigma float64 // Standard deviation of the spatial weight in pixels, defaults to 3.
+	RangeSigma   float64 // Standard deviation of the intensity weight in levels, defaults to 30.
+	Radius       int     // Radius of the window in pixels, defaults to 2*SpatialSigma rounded up.
+}
+
+// NLMeansOptions configures a non-local means denoiser. Zero fields fall back to their defaults.
+type NLMeansOptions struct {
+	PatchRadius  int     // Radius of the patches that are compared, defaults to 1 (3x3 patches).
+	SearchRadius int     // Radius of the window searched for similar patches, defaults to 7.
+	H            float64 // Filtering strength in levels, higher removes more noise, defaults to 10.
+}
+
+func (opts BilateralOptions) withDefaults() BilateralOptions {
+	if opts.SpatialSigma <= 0 {
+		opts.SpatialSigma = 3
+	}
+	if opts.RangeSigma <= 0 {
+		opts.RangeSigma = 30
+	}
+	if opts.Radius <= 0 {
+		opts.Radius = int(math.Ceil(2 * opts.SpatialSigma))
+	}
+	return opts
+}
+
+func (opts NLMeansOptions) withDefaults() NLMeansOptions {
+	if opts.PatchRadius <= 0 {
+		opts.PatchRadius = 1
+	}
+	if opts.SearchRadius <= 0 {
+		opts.SearchRadius = 7
+	}
+	if opts.H <= 0 {
+		opts.H = 10
+	}
+	return opts
+}
+
+// bilateralPlanes filters the planes together: the intensity weight of a neighbor uses the
+// distance between the samples of all the planes, so that color edges are preserved in PPM.
+func bilateralPlanes(planes [][][]float64, opts BilateralOptions) [][][]float64 {
+	height, width := len(planes[0]), len(planes[0][0])
+	r := opts.Radius
+	spatial := make([][]float64, 2*r+1) // Spatial weights only depend on the offset.
+	for j := -r; j <= r; j++ {
+		spatial[j+r] = make([]float64, 2*r+1)
+		for i := -r; i <= r; i++ {
+			spatial[j+r][i+r] = math.Exp(-float64(i*i+j*j) / (2 * opts.SpatialSigma * opts.SpatialSigma))
+		}
+	}
+	rangeDenominator := 2 * opts.RangeSigma * opts.RangeSigma
+
+	out := make([][][]float64, len(planes))
+	for c := range out {
+		out[c] = newPlane(width, height)
+	}
+	sums := make([]float64, len(planes))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			total := 0.0
+			for c := range sums {
+				sums[c] = 0
+			}
+			for j := max(y-r, 0); j <= min(y+r, height-1); j++ {
+				for i := max(x-r, 0); i <= min(x+r, width-1); i++ {
+					d := 0.0
+					for c := range planes {
+						diff := planes[c][j][i] - planes[c][y][x]
+						d += diff * diff
+					}
+					w := spatial[j-y+r][i-x+r] * math.Exp(-d/rangeDenominator)
+					for c := range planes {
+						sums[c] += w * planes[c][j][i]
+					}
+					total += w
+				}
+			} // The center pixel always has weight 1, so total is never zero.
+			for c := range out {
+				out[c][y][x] = sums[c] / total
+			}
+		}
+	}
+	return out
+}
+
+// nlMeansPlanes denoises the planes by replacing every pixel with an average of the pixels in
+// the search window, weighted by how similar the patches around them are.
+func nlMeansPlanes(planes [][][]float64, opts NLMeansOptions) [][][]float64 {
+	height, width := len(planes[0]), len(planes[0][0])
+	pr, sr := opts.PatchRadius, opts.SearchRadius
+	patchSize := float64((2*pr + 1) * (2*pr + 1) * len(planes))
+	h2 := opts.H * opts.H
+
+	out := make([][][]float64, len(planes))
+	for c := range out {
+		out[c] = newPlane(width, height)
+	}
+	sums := make([]float64, len(planes))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			total := 0.0
+			for c := range sums {
+				sums[c] = 0
+			}
+			for j := max(y-sr, 0); j <= min(y+sr, height-1); j++ {
+				for i := max(x-sr, 0); i <= min(x+sr, width-1); i++ {
+					d := 0.0
+					for c := range planes {
+						for dy := -pr; dy <= pr; dy++ {
+							for dx := -pr; dx <= pr; dx++ {
+								diff := planeAt(planes[c], x+dx, y+dy, BorderMirror, 0) - planeAt(planes[c], i+dx, j+dy, BorderMirror, 0)
+								d += diff * diff
+							}
+						}
+					} // Mean squared distance between the two patches.
+					w := math.Exp(-d / patchSize / h2)
+					for c := range planes {
+						sums[c] += w * planes[c][j][i]
+					}
+					total += w
+				}
+			}
+			for c := range out {
+				out[c][y][x] = sums[c] / total
+			}
+		}
+	}
+	return out
+}
+
+// BilateralFilter smooths the PGM image while preserving edges: each pixel becomes an average
+// of its neighbors weighted both by their distance and by how close their values are.
+func (pgm *PGM) BilateralFilter(opts BilateralOptions) {
+	if pgm.width == 0 || pgm.height == 0 {
+		return
+	}
+	pgm.setPlane(bilateralPlanes([][][]float64{pgm.plane()}, opts.withDefaults())[0])
+}
+
+// NonLocalMeans denoises the PGM image by averaging pixels whose surrounding patches look alike.
+// It is slower than the other filters, the cost grows with the square of both radii.
+func (pgm *PGM) NonLocalMeans(opts NLMeansOptions) {
+	if pgm.width == 0 || pgm.height == 0 {
+		return
+	}
+	pgm.setPlane(nlMeansPlanes([][][]float64{pgm.plane()}, opts.withDefaults())[0])
+}
+
+// BilateralFilter smooths the PPM image while preserving edges, see PGM.BilateralFilter. The
+// intensity weight uses the color distance, so edges between colors of equal brightness survive.
+func (ppm *PPM) BilateralFilter(opts BilateralOptions) {
+	if ppm.width == 0 || ppm.height == 0 {
+		return
+	}
+	planes := ppm.planes()
+	out := bilateralPlanes(planes[:], opts.withDefaults())
+	ppm.setPlanes([3][][]float64{out[0], out[1], out[2]})
+}
+
+// NonLocalMeans denoises the PPM image, comparing patches over the three channels at once.
+func (ppm *PPM) NonLocalMeans(opts NLMeansOptions) {
+	if ppm.width == 0 || ppm.height == 0 {
+		return
+	}
+	planes := ppm.planes()
+	out := nlMeansPlanes(planes[:], opts.withDefaults())
+	ppm.setPlanes([3][][]float64{out[0], out[1], out[2]})
+}
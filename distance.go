@@ -0,0 +1,174 @@
+package Netpbm
+
+import "math"
+
+// DistanceMetric selects how distances are measured by PBM.DistanceTransform.
+type DistanceMetric int
+
+const (
+	DistanceEuclidean DistanceMetric = iota // Exact straight line distance.
+	DistanceChamfer                         // 3-4 chamfer approximation of the Euclidean distance.
+	DistanceManhattan                       // Sum of the horizontal and vertical distances.
+)
+
+// DistanceTransform computes, for every pixel of the PBM image, the distance to the nearest
+// true pixel. It returns the distances and a PGM visualization where they are scaled so that
+// the largest one maps to 255. When the image has no true pixel every distance is +Inf and
+// the visualization is white.
+func (pbm *PBM) DistanceTransform(metric DistanceMetric) ([][]float64, *PGM) {
+	var field [][]float64
+	switch metric {
+	case DistanceChamfer:
+		field = chamferDistance(pbm, 3, 4, 3) // Weights 3 and 4 approximate 1 and sqrt(2).
+	case DistanceManhattan:
+		field = chamferDistance(pbm, 1, math.Inf(1), 1) // Diagonal steps are replaced by two straight ones.
+	default:
+		field = squaredEDT(pbm.width, pbm.height, func(x, y int) bool {
+			return pbm.data[y][x]
+		})
+		for y := range field {
+			for x := range field[y] {
+				field[y][x] = math.Sqrt(field[y][x])
+			}
+		}
+	}
+
+	largest := 0.0
+	for y := range field {
+		for x := range field[y] {
+			if !math.IsInf(field[y][x], 1) {
+				largest = max(largest, field[y][x])
+			}
+		}
+	}
+	visual := newPGM(pbm.width, pbm.height, "P2", 255)
+	for y := range field {
+		for x := range field[y] {
+			switch {
+			case math.IsInf(field[y][x], 1):
+				visual.data[y][x] = 255
+			case largest > 0:
+				visual.data[y][x] = clampToMax(field[y][x]/largest*255, 255)
+			}
+		}
+	}
+	return field, visual
+}
+
+// chamferDistance computes a chamfer distance transform with two raster scans, using the
+// weight straight for horizontal and vertical steps and diagonal for diagonal ones. The
+// results are divided by scale.
+func chamferDistance(pbm *PBM, straight, diagonal, scale float64) [][]float64 {
+	field := newPlane(pbm.width, pbm.height)
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			if !pbm.data[y][x] {
+				field[y][x] = math.Inf(1)
+			}
+		}
+	}
+	at := func(x, y int) float64 {
+		if x < 0 || y < 0 || x >= pbm.width || y >= pbm.height {
+			return math.Inf(1)
+		}
+		return field[y][x]
+	}
+
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			d := field[y][x]
+			d = min(d, at(x-1, y)+straight, at(x, y-1)+straight)
+			d = min(d, at(x-1, y-1)+diagonal, at(x+1, y-1)+diagonal)
+			field[y][x] = d
+		}
+	} // Forward pass from the top-left corner.
+	for y := pbm.height - 1; y >= 0; y-- {
+		for x := pbm.width - 1; x >= 0; x-- {
+			d := field[y][x]
+			d = min(d, at(x+1, y)+straight, at(x, y+1)+straight)
+			d = min(d, at(x+1, y+1)+diagonal, at(x-1, y+1)+diagonal)
+			field[y][x] = d
+		}
+	} // Backward pass from the bottom-right corner.
+
+	for y := range field {
+		for x := range field[y] {
+			field[y][x] /= scale
+		}
+	}
+	return field
+}
+
+// squaredEDT returns the squared Euclidean distance from every pixel of a width x height grid
+// to the nearest pixel for which isTarget is true, or +Inf when there is no target. It uses the
+// exact linear time algorithm of Felzenszwalb and Huttenlocher, one pass per axis.
+func squaredEDT(width, height int, isTarget func(x, y int) bool) [][]float64 {
+	grid := newPlane(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if !isTarget(x, y) {
+				grid[y][x] = math.Inf(1)
+			}
+		}
+	}
+
+	column := make([]float64, height)
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			column[y] = grid[y][x]
+		}
+		column = edt1D(column)
+		for y := 0; y < height; y++ {
+			grid[y][x] = column[y]
+		}
+	} // Vertical pass.
+	for y := 0; y < height; y++ {
+		grid[y] = edt1D(grid[y])
+	} // Horizontal pass over the column distances.
+	return grid
+}
+
+// edt1D returns the 1D squared distance transform of the sampled function f, that is
+// min over q of (p-q)^2 + f(q) for every p, by computing the lower envelope of the parabolas
+// rooted at each sample.
+func edt1D(f []float64) []float64 {
+	n := len(f)
+	d := make([]float64, n)
+	v := make([]int, 0, n)       // Locations of the parabolas in the lower envelope.
+	z := make([]float64, 0, n+1) // Boundaries between consecutive parabolas.
+	for q := 0; q < n; q++ {
+		if math.IsInf(f[q], 1) {
+			continue
+		} // Samples at infinity never contribute to the envelope.
+		for len(v) > 0 {
+			p := v[len(v)-1]
+			s := ((f[q] + float64(q*q)) - (f[p] + float64(p*p))) / float64(2*(q-p))
+			if s > z[len(z)-1] {
+				v = append(v, q)
+				z = append(z, s)
+				break
+			}
+			v = v[:len(v)-1]
+			z = z[:len(z)-1]
+		}
+		if len(v) == 0 {
+			v = append(v, q)
+			z = append(z, math.Inf(-1))
+		}
+	}
+	if len(v) == 0 {
+		for i := range d {
+			d[i] = math.Inf(1)
+		}
+		return d
+	}
+	k := 0
+	for q := 0; q < n; q++ {
+		for k+1 < len(z) && z[k+1] < float64(q) {
+			k++
+		}
+		diff := float64(q - v[k])
+		d[q] = diff*diff + f[v[k]]
+	}
+	return d
+}
@@ -0,0 +1,177 @@
+package Netpbm
+
+import "math"
+
+// GradientOperator selects the derivative kernels used to compute image gradients.
+type GradientOperator int
+
+const (
+	OperatorSobel   GradientOperator = iota // 3x3 Sobel kernels.
+	OperatorPrewitt                         // 3x3 Prewitt kernels.
+	OperatorScharr                          // 3x3 Scharr kernels, more rotationally accurate than Sobel.
+)
+
+// derivativeKernel returns the horizontal derivative kernel of the operator and its gain,
+// the response to a unit step edge. The vertical kernel is its transpose.
+func (op GradientOperator) derivativeKernel() (Kernel, float64) {
+	switch op {
+	case OperatorPrewitt:
+		return Kernel{{-1, 0, 1}, {-1, 0, 1}, {-1, 0, 1}}, 3
+	case OperatorScharr:
+		return Kernel{{-3, 0, 3}, {-10, 0, 10}, {-3, 0, 3}}, 16
+	}
+	return Kernel{{-1, 0, 1}, {-2, 0, 2}, {-1, 0, 1}}, 4
+}
+
+// gradientPlanes returns the horizontal and vertical derivatives of the plane divided by the
+// operator gain, so that a step edge of height h gives a derivative of h.
+func gradientPlanes(plane [][]float64, op GradientOperator) ([][]float64, [][]float64) {
+	k, gain := op.derivativeKernel()
+	height := len(plane)
+	width := 0
+	if height > 0 {
+		width = len(plane[0])
+	}
+	gx, gy := newPlane(width, height), newPlane(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sx, sy := 0.0, 0.0
+			for j := -1; j <= 1; j++ {
+				for i := -1; i <= 1; i++ {
+					v := planeAt(plane, x+i, y+j, BorderClamp, 0)
+					sx += k[j+1][i+1] * v
+					sy += k[i+1][j+1] * v // The vertical kernel is the transposed horizontal one.
+				}
+			}
+			gx[y][x], gy[y][x] = sx/gain, sy/gain
+		}
+	}
+	return gx, gy
+}
+
+// gradientImages converts derivative planes into magnitude and direction PGM images. The
+// direction maps angles from -pi to pi linearly onto [0, max].
+func gradientImages(gx, gy [][]float64, width, height int, max uint8) (*PGM, *PGM) {
+	magnitude := newPGM(width, height, "P2", max)
+	direction := newPGM(width, height, "P2", max)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			magnitude.data[y][x] = clampToMax(math.Hypot(gx[y][x], gy[y][x]), max)
+			angle := math.Atan2(gy[y][x], gx[y][x])
+			direction.data[y][x] = clampToMax((angle+math.Pi)/(2*math.Pi)*float64(max), max)
+		}
+	}
+	return magnitude, direction
+}
+
+// Gradient computes the gradient of the PGM image with the operator and returns its
+// magnitude and its direction as new PGM images with the same max value.
+func (pgm *PGM) Gradient(op GradientOperator) (magnitude, direction *PGM) {
+	gx, gy := gradientPlanes(pgm.plane(), op)
+	return gradientImages(gx, gy, pgm.width, pgm.height, pgm.max)
+}
+
+// Gradient computes the gradient of the grayscale version of the PPM image, see PGM.Gradient.
+func (ppm *PPM) Gradient(op GradientOperator) (magnitude, direction *PGM) {
+	return ppm.ToPGM().Gradient(op)
+}
+
+// Laplacian returns the absolute response of the PGM image to the 3x3 Laplacian kernel as a
+// new PGM image. Responses are clamped to max.
+func (pgm *PGM) Laplacian() *PGM {
+	k := Kernel{{0, 1, 0}, {1, -4, 1}, {0, 1, 0}}
+	response := convolvePlane(pgm.plane(), k, BorderClamp, 0)
+	out := newPGM(pgm.width, pgm.height, "P2", pgm.max)
+	for y := 0; y < pgm.height; y++ {
+		for x := 0; x < pgm.width; x++ {
+			out.data[y][x] = clampToMax(math.Abs(response[y][x]), pgm.max)
+		}
+	}
+	return out
+}
+
+// Laplacian returns the Laplacian of the grayscale version of the PPM image, see PGM.Laplacian.
+func (ppm *PPM) Laplacian() *PGM {
+	return ppm.ToPGM().Laplacian()
+}
+
+// Canny detects edges in the PGM image with the Canny algorithm and returns them as a PBM
+// mask where edge pixels are true. The image is first smoothed with a Gaussian of standard
+// deviation sigma (skipped when sigma is zero). Pixels whose Sobel gradient magnitude is a
+// local maximum and reaches high are edges, as are those above low connected to them.
+func (pgm *PGM) Canny(sigma float64, low, high uint8) *PBM {
+	plane := pgm.plane()
+	if sigma > 0 && pgm.height > 0 {
+		plane = gaussianPlane(plane, sigma)
+	}
+	gx, gy := gradientPlanes(plane, OperatorSobel)
+	width, height := pgm.width, pgm.height
+
+	magnitude := newPlane(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			magnitude[y][x] = math.Hypot(gx[y][x], gy[y][x])
+		}
+	}
+
+	thin := newPlane(width, height) // Non-maximum suppression keeps only the ridges of the magnitude.
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			m := magnitude[y][x]
+			if m == 0 {
+				continue
+			}
+			angle := math.Atan2(gy[y][x], gx[y][x]) * 180 / math.Pi
+			if angle < 0 {
+				angle += 180
+			}
+			var dx, dy int // Step towards the neighbor across the edge.
+			switch {
+			case angle < 22.5 || angle >= 157.5:
+				dx, dy = 1, 0
+			case angle < 67.5:
+				dx, dy = 1, 1
+			case angle < 112.5:
+				dx, dy = 0, 1
+			default:
+				dx, dy = -1, 1
+			}
+			if m >= planeAt(magnitude, x+dx, y+dy, BorderConstant, 0) && m >= planeAt(magnitude, x-dx, y-dy, BorderConstant, 0) {
+				thin[y][x] = m
+			}
+		}
+	}
+
+	edges := newPBM(width, height, "P1") // Hysteresis: grow strong edges through weak pixels.
+	var stack []Point
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if thin[y][x] >= float64(high) && thin[y][x] > 0 {
+				edges.data[y][x] = true
+				stack = append(stack, Point{x, y})
+			}
+		}
+	}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				nx, ny := p.X+dx, p.Y+dy
+				if nx < 0 || ny < 0 || nx >= width || ny >= height || edges.data[ny][nx] {
+					continue
+				}
+				if thin[ny][nx] >= float64(low) && thin[ny][nx] > 0 {
+					edges.data[ny][nx] = true
+					stack = append(stack, Point{nx, ny})
+				}
+			}
+		}
+	}
+	return edges
+}
+
+// Canny detects edges in the grayscale version of the PPM image, see PGM.Canny.
+func (ppm *PPM) Canny(sigma float64, low, high uint8) *PBM {
+	return ppm.ToPGM().Canny(sigma, low, high)
+}
@@ -0,0 +1,135 @@
+package Netpbm
+
+// FillOptions controls which pixels a flood fill or a selection reaches.
+type FillOptions struct {
+	Connectivity Connectivity // Neighbors the region grows through, Connectivity4 when zero.
+	Tolerance    uint8        // Largest per-channel difference with the seed color that is still filled.
+}
+
+// scanlineRegion returns the mask of the region of a width x height image connected to the seed
+// through pixels for which match is true. Whole horizontal runs are filled at once and only the
+// start of each run of the rows above and below is pushed, so the stack stays small even for
+// huge regions.
+func scanlineRegion(width, height int, seed Point, connectivity Connectivity, match func(x, y int) bool) *PBM {
+	region := newPBM(width, height, "P1")
+	if seed.X < 0 || seed.Y < 0 || seed.X >= width || seed.Y >= height {
+		return region
+	}
+	inside := func(x, y int) bool {
+		return !region.data[y][x] && match(x, y)
+	}
+	reach := 0
+	if connectivity == Connectivity8 {
+		reach = 1
+	} // With 8-connectivity the runs above and below may start one pixel diagonally off.
+
+	stack := []Point{seed}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !inside(p.X, p.Y) {
+			continue
+		}
+		left, right := p.X, p.X
+		for left > 0 && inside(left-1, p.Y) {
+			left--
+		}
+		for right < width-1 && inside(right+1, p.Y) {
+			right++
+		}
+		for x := left; x <= right; x++ {
+			region.data[p.Y][x] = true
+		} // Fill the whole run containing the popped point.
+
+		for _, ny := range []int{p.Y - 1, p.Y + 1} {
+			if ny < 0 || ny >= height {
+				continue
+			}
+			inRun := false
+			for x := max(left-reach, 0); x <= min(right+reach, width-1); x++ {
+				if inside(x, ny) {
+					if !inRun {
+						stack = append(stack, Point{x, ny})
+						inRun = true
+					}
+				} else {
+					inRun = false
+				}
+			} // Push one point per run of matching pixels touching the filled run.
+		}
+	}
+	return region
+}
+
+// withinTolerance reports whether a and b differ by at most tolerance.
+func withinTolerance(a, b, tolerance uint8) bool {
+	if a > b {
+		return a-b <= tolerance
+	}
+	return b-a <= tolerance
+}
+
+// Select returns a PBM mask of the pixels of the PBM image connected to the seed that have
+// the same value as the seed. The tolerance of opts is ignored since pixels are binary.
+func (pbm *PBM) Select(seed Point, opts FillOptions) *PBM {
+	if seed.X < 0 || seed.Y < 0 || seed.X >= pbm.width || seed.Y >= pbm.height {
+		return newPBM(pbm.width, pbm.height, "P1")
+	}
+	target := pbm.data[seed.Y][seed.X]
+	return scanlineRegion(pbm.width, pbm.height, seed, opts.Connectivity, func(x, y int) bool {
+		return pbm.data[y][x] == target
+	})
+}
+
+// FloodFill sets to value the pixels of the PBM image that Select would return.
+func (pbm *PBM) FloodFill(seed Point, value bool, opts FillOptions) {
+	pbm.Select(seed, opts).eachSet(func(x, y int) { pbm.data[y][x] = value })
+}
+
+// eachSet calls f with the coordinates of every true pixel of the PBM image, used as a mask.
+func (pbm *PBM) eachSet(f func(x, y int)) {
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			if pbm.data[y][x] {
+				f(x, y)
+			}
+		}
+	}
+}
+
+// Select returns a PBM mask of the pixels of the PGM image connected to the seed whose value
+// is within the tolerance of the seed value, like a magic wand.
+func (pgm *PGM) Select(seed Point, opts FillOptions) *PBM {
+	if seed.X < 0 || seed.Y < 0 || seed.X >= pgm.width || seed.Y >= pgm.height {
+		return newPBM(pgm.width, pgm.height, "P1")
+	}
+	target := pgm.data[seed.Y][seed.X]
+	return scanlineRegion(pgm.width, pgm.height, seed, opts.Connectivity, func(x, y int) bool {
+		return withinTolerance(pgm.data[y][x], target, opts.Tolerance)
+	})
+}
+
+// FloodFill sets to value the pixels of the PGM image that Select would return.
+func (pgm *PGM) FloodFill(seed Point, value uint8, opts FillOptions) {
+	pgm.Select(seed, opts).eachSet(func(x, y int) { pgm.data[y][x] = value })
+}
+
+// Select returns a PBM mask of the pixels of the PPM image connected to the seed whose color
+// is within the tolerance of the seed color on every channel, like a magic wand.
+func (ppm *PPM) Select(seed Point, opts FillOptions) *PBM {
+	if seed.X < 0 || seed.Y < 0 || seed.X >= ppm.width || seed.Y >= ppm.height {
+		return newPBM(ppm.width, ppm.height, "P1")
+	}
+	target := ppm.data[seed.Y][seed.X]
+	return scanlineRegion(ppm.width, ppm.height, seed, opts.Connectivity, func(x, y int) bool {
+		p := ppm.data[y][x]
+		return withinTolerance(p.R, target.R, opts.Tolerance) &&
+			withinTolerance(p.G, target.G, opts.Tolerance) &&
+			withinTolerance(p.B, target.B, opts.Tolerance)
+	})
+}
+
+// FloodFill sets to color the pixels of the PPM image that Select would return.
+func (ppm *PPM) FloodFill(seed Point, color Pixel, opts FillOptions) {
+	ppm.Select(seed, opts).eachSet(func(x, y int) { ppm.data[y][x] = color })
+}
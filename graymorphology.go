@@ -0,0 +1,141 @@
+package Netpbm
+
+import "fmt"
+
+// height returns the height of the i-th offset of the element, 0 for a flat element.
+func (se StructuringElement) height(i int) int {
+	if i < len(se.Heights) {
+		return se.Heights[i]
+	}
+	return 0
+}
+
+// Erode returns a new PGM image where each pixel is the minimum over the element placed on it
+// of the pixel value minus the element height. Pixels outside of the image are ignored.
+func (pgm *PGM) Erode(se StructuringElement) *PGM {
+	out := newPGM(pgm.width, pgm.height, pgm.magicNumber, pgm.max)
+	for y := 0; y < pgm.height; y++ {
+		for x := 0; x < pgm.width; x++ {
+			value := int(pgm.max)
+			for i, o := range se.Offsets {
+				nx, ny := x+o.X, y+o.Y
+				if nx >= 0 && ny >= 0 && nx < pgm.width && ny < pgm.height {
+					value = min(value, int(pgm.data[ny][nx])-se.height(i))
+				}
+			}
+			out.data[y][x] = uint8(min(max(value, 0), int(pgm.max)))
+		}
+	}
+	return out
+}
+
+// Dilate returns a new PGM image where each pixel is the maximum over the reflected element
+// placed on it of the pixel value plus the element height. Pixels outside of the image are ignored.
+func (pgm *PGM) Dilate(se StructuringElement) *PGM {
+	out := newPGM(pgm.width, pgm.height, pgm.magicNumber, pgm.max)
+	for y := 0; y < pgm.height; y++ {
+		for x := 0; x < pgm.width; x++ {
+			value := 0
+			for i, o := range se.Offsets {
+				nx, ny := x-o.X, y-o.Y
+				if nx >= 0 && ny >= 0 && nx < pgm.width && ny < pgm.height {
+					value = max(value, int(pgm.data[ny][nx])+se.height(i))
+				}
+			}
+			out.data[y][x] = uint8(min(max(value, 0), int(pgm.max)))
+		}
+	}
+	return out
+}
+
+// Open returns the erosion of the PGM image followed by a dilation, which removes bright
+// details smaller than the element.
+func (pgm *PGM) Open(se StructuringElement) *PGM {
+	return pgm.Erode(se).Dilate(se)
+}
+
+// Close returns the dilation of the PGM image followed by an erosion, which removes dark
+// details smaller than the element.
+func (pgm *PGM) Close(se StructuringElement) *PGM {
+	return pgm.Dilate(se).Erode(se)
+}
+
+// TopHat returns the PGM image minus its opening (white top-hat), which keeps the bright
+// details smaller than the element and flattens uneven backgrounds.
+func (pgm *PGM) TopHat(se StructuringElement) *PGM {
+	out, _ := pgm.Subtract(pgm.Open(se), false) // Same size, can't fail.
+	return out
+}
+
+// BlackHat returns the closing of the PGM image minus the image (black top-hat), which keeps
+// the dark details smaller than the element.
+func (pgm *PGM) BlackHat(se StructuringElement) *PGM {
+	out, _ := pgm.Close(se).Subtract(pgm, false)
+	return out
+}
+
+// ReconstructByDilation returns the morphological reconstruction of mask from the PGM image
+// used as marker: the marker is dilated repeatedly with 8-connectivity, without ever rising
+// above mask, until it stops changing. It fills the regions of mask that the marker touches.
+func (pgm *PGM) ReconstructByDilation(mask *PGM) (*PGM, error) {
+	return pgm.reconstruct(mask, true)
+}
+
+// ReconstructByErosion returns the morphological reconstruction by erosion of mask from the
+// PGM image used as marker: the marker is eroded repeatedly without ever going below mask.
+func (pgm *PGM) ReconstructByErosion(mask *PGM) (*PGM, error) {
+	return pgm.reconstruct(mask, false)
+}
+
+// reconstruct implements both reconstructions with alternating forward and backward raster
+// scans, each scan propagating values from the neighbors already visited, until stability.
+func (pgm *PGM) reconstruct(mask *PGM, dilation bool) (*PGM, error) {
+	if pgm.width != mask.width || pgm.height != mask.height {
+		return nil, fmt.Errorf("marker is %dx%d but mask is %dx%d", pgm.width, pgm.height, mask.width, mask.height)
+	}
+	better := func(a, b uint8) uint8 { // Propagation keeps the max for dilation, the min for erosion.
+		if (a > b) == dilation {
+			return a
+		}
+		return b
+	}
+	limit := func(a, m uint8) uint8 { // The result never crosses the mask.
+		if (a > m) == dilation {
+			return m
+		}
+		return a
+	}
+
+	out := newPGM(pgm.width, pgm.height, mask.magicNumber, mask.max)
+	for y := 0; y < pgm.height; y++ {
+		for x := 0; x < pgm.width; x++ {
+			out.data[y][x] = limit(pgm.data[y][x], mask.data[y][x])
+		}
+	}
+
+	forward := []Point{{-1, -1}, {0, -1}, {1, -1}, {-1, 0}} // Neighbors visited before (x, y) in a raster scan.
+	for changed := true; changed; {
+		changed = false
+		for pass := 0; pass < 2; pass++ {
+			for i := 0; i < pgm.width*pgm.height; i++ {
+				y, x, sign := i/pgm.width, i%pgm.width, 1
+				if pass == 1 {
+					y, x, sign = pgm.height-1-y, pgm.width-1-x, -1
+				} // The backward pass walks the image in reverse with mirrored neighbors.
+				v := out.data[y][x]
+				for _, n := range forward {
+					nx, ny := x+sign*n.X, y+sign*n.Y
+					if nx >= 0 && ny >= 0 && nx < pgm.width && ny < pgm.height {
+						v = better(v, out.data[ny][nx])
+					}
+				}
+				v = limit(v, mask.data[y][x])
+				if v != out.data[y][x] {
+					out.data[y][x] = v
+					changed = true
+				}
+			}
+		}
+	}
+	return out, nil
+}
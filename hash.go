@@ -0,0 +1,201 @@
+package Netpbm
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+)
+
+// Hash is a perceptual fingerprint of an image, packed 64 bits per word. Similar images have
+// hashes with a small Hamming distance.
+type Hash []uint64
+
+// set sets the i-th bit of the hash.
+func (h Hash) set(i int) {
+	h[i/64] |= 1 << (63 - uint(i%64))
+}
+
+// newHash allocates a hash holding n bits.
+func newHash(n int) Hash {
+	return make(Hash, (n+63)/64)
+}
+
+// Distance returns the Hamming distance between the hashes, the number of bits that differ.
+// Words missing from the shorter hash count as fully different.
+func (h Hash) Distance(other Hash) int {
+	distance := 0
+	for i := 0; i < max(len(h), len(other)); i++ {
+		if i >= len(h) || i >= len(other) {
+			distance += 64
+			continue
+		}
+		distance += bits.OnesCount64(h[i] ^ other[i])
+	}
+	return distance
+}
+
+// String returns the hash in hexadecimal.
+func (h Hash) String() string {
+	var sb strings.Builder
+	for _, w := range h {
+		fmt.Fprintf(&sb, "%016x", w)
+	}
+	return sb.String()
+}
+
+// resizePlane returns the plane resampled to width x height, each output sample being the
+// mean of the input area it covers.
+func resizePlane(plane [][]float64, width, height int) [][]float64 {
+	srcHeight, srcWidth := len(plane), len(plane[0])
+	sx, sy := float64(srcWidth)/float64(width), float64(srcHeight)/float64(height)
+	out := newPlane(width, height)
+	for y := 0; y < height; y++ {
+		y0, y1 := float64(y)*sy, float64(y+1)*sy
+		for x := 0; x < width; x++ {
+			x0, x1 := float64(x)*sx, float64(x+1)*sx
+			sum, area := 0.0, 0.0
+			for j := int(y0); j < srcHeight && float64(j) < y1; j++ {
+				wy := math.Min(float64(j+1), y1) - math.Max(float64(j), y0)
+				for i := int(x0); i < srcWidth && float64(i) < x1; i++ {
+					w := wy * (math.Min(float64(i+1), x1) - math.Max(float64(i), x0)) // Covered part of the source pixel.
+					sum += w * plane[j][i]
+					area += w
+				}
+			}
+			out[y][x] = sum / area
+		}
+	}
+	return out
+}
+
+// median returns the median of the values.
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// thresholdHash sets the bits of the values strictly above the threshold.
+func thresholdHash(values []float64, threshold float64) Hash {
+	h := newHash(len(values))
+	for i, v := range values {
+		if v > threshold {
+			h.set(i)
+		}
+	}
+	return h
+}
+
+// flatten returns the samples of the plane row by row.
+func flatten(plane [][]float64) []float64 {
+	var values []float64
+	for _, row := range plane {
+		values = append(values, row...)
+	}
+	return values
+}
+
+// AverageHash returns the 64-bit average hash (aHash) of the PGM image: the image is reduced to
+// 8x8 and each bit tells whether a pixel is brighter than the mean.
+func (pgm *PGM) AverageHash() Hash {
+	if pgm.width == 0 || pgm.height == 0 {
+		return newHash(64)
+	}
+	values := flatten(resizePlane(pgm.plane(), 8, 8))
+	return thresholdHash(values, sumOf(values)/float64(len(values)))
+}
+
+// DifferenceHash returns the 64-bit difference hash (dHash) of the PGM image: the image is
+// reduced to 9x8 and each bit tells whether a pixel is brighter than its right neighbor.
+func (pgm *PGM) DifferenceHash() Hash {
+	h := newHash(64)
+	if pgm.width == 0 || pgm.height == 0 {
+		return h
+	}
+	small := resizePlane(pgm.plane(), 9, 8)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if small[y][x] > small[y][x+1] {
+				h.set(y*8 + x)
+			}
+		}
+	}
+	return h
+}
+
+// PerceptualHash returns the 64-bit DCT-based perceptual hash (pHash) of the PGM image: the
+// image is reduced to 32x32, and each bit tells whether one of the 8x8 lowest frequency DCT
+// coefficients is above their median. It is robust to scaling, gamma and compression changes.
+func (pgm *PGM) PerceptualHash() Hash {
+	if pgm.width == 0 || pgm.height == 0 {
+		return newHash(64)
+	}
+	const size, low = 32, 8
+	small := resizePlane(pgm.plane(), size, size)
+	cosines := make([][]float64, low) // cosines[u][x] = cos((2x+1)u pi / 2N), shared by both axes.
+	for u := range cosines {
+		cosines[u] = make([]float64, size)
+		for x := range cosines[u] {
+			cosines[u][x] = math.Cos(float64((2*x+1)*u) * math.Pi / (2 * size))
+		}
+	}
+	rows := newPlane(low, size) // DCT of every row, low frequencies only.
+	for y := 0; y < size; y++ {
+		for u := 0; u < low; u++ {
+			for x := 0; x < size; x++ {
+				rows[y][u] += small[y][x] * cosines[u][x]
+			}
+		}
+	}
+	coefficients := make([]float64, 0, low*low)
+	for v := 0; v < low; v++ {
+		for u := 0; u < low; u++ {
+			c := 0.0
+			for y := 0; y < size; y++ {
+				c += rows[y][u] * cosines[v][y]
+			}
+			coefficients = append(coefficients, c)
+		}
+	}
+	return thresholdHash(coefficients, median(coefficients[1:])) // The DC term only measures brightness.
+}
+
+// BlockMeanHash returns the block mean hash of the PGM image with blocks x blocks bits: the
+// image is split into that many blocks and each bit tells whether the mean of a block is above
+// the median of all the means. A block count below 1 gives an empty hash.
+func (pgm *PGM) BlockMeanHash(blocks int) Hash {
+	if blocks <= 0 {
+		return newHash(0)
+	}
+	if pgm.width == 0 || pgm.height == 0 {
+		return newHash(blocks * blocks)
+	}
+	values := flatten(resizePlane(pgm.plane(), blocks, blocks))
+	return thresholdHash(values, median(values))
+}
+
+// AverageHash returns the average hash of the grayscale version of the PPM image.
+func (ppm *PPM) AverageHash() Hash {
+	return ppm.ToPGM().AverageHash()
+}
+
+// DifferenceHash returns the difference hash of the grayscale version of the PPM image.
+func (ppm *PPM) DifferenceHash() Hash {
+	return ppm.ToPGM().DifferenceHash()
+}
+
+// PerceptualHash returns the perceptual hash of the grayscale version of the PPM image.
+func (ppm *PPM) PerceptualHash() Hash {
+	return ppm.ToPGM().PerceptualHash()
+}
+
+// BlockMeanHash returns the block mean hash of the grayscale version of the PPM image.
+func (ppm *PPM) BlockMeanHash(blocks int) Hash {
+	return ppm.ToPGM().BlockMeanHash(blocks)
+}
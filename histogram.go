@@ -0,0 +1,228 @@
+package Netpbm
+
+import "math"
+
+// histogramOf counts the occurrences of every value from 0 to max in the channel. Values above
+// max are counted as max.
+func histogramOf(channel [][]uint8, max uint8) []int {
+	hist := make([]int, int(max)+1)
+	for _, row := range channel {
+		for _, v := range row {
+			hist[min(v, max)]++
+		}
+	}
+	return hist
+}
+
+// applyLUT replaces every value of the channel with its entry in the lookup table.
+func applyLUT(channel [][]uint8, lut []uint8) {
+	for _, row := range channel {
+		for x, v := range row {
+			row[x] = lut[min(int(v), len(lut)-1)]
+		}
+	}
+}
+
+// CumulativeHistogram returns the running sums of the histogram, the value at index v being
+// the number of pixels whose value is v or less.
+func CumulativeHistogram(hist []int) []int {
+	cumulative := make([]int, len(hist))
+	sum := 0
+	for i, count := range hist {
+		sum += count
+		cumulative[i] = sum
+	}
+	return cumulative
+}
+
+// equalizationLUT returns the lookup table that spreads the values of the histogram evenly
+// over [0, max].
+func equalizationLUT(hist []int, max uint8) []uint8 {
+	cdf := CumulativeHistogram(hist)
+	total := cdf[len(cdf)-1]
+	cdfMin := 0
+	for _, c := range cdf {
+		if c > 0 {
+			cdfMin = c
+			break
+		}
+	} // The darkest value present maps to 0.
+	lut := make([]uint8, len(hist))
+	for v := range lut {
+		if total > cdfMin {
+			lut[v] = clampToMax(float64(cdf[v]-cdfMin)/float64(total-cdfMin)*float64(max), max)
+		} else {
+			lut[v] = uint8(v)
+		} // A uniform image is left unchanged.
+	}
+	return lut
+}
+
+// matchingLUT returns the lookup table that gives a channel with histogram src the shape of
+// histogram ref, mapping each value to the first reference value with at least the same
+// cumulative proportion.
+func matchingLUT(src, ref []int) []uint8 {
+	srcCDF, refCDF := CumulativeHistogram(src), CumulativeHistogram(ref)
+	srcTotal, refTotal := float64(srcCDF[len(srcCDF)-1]), float64(refCDF[len(refCDF)-1])
+	lut := make([]uint8, len(src))
+	r := 0
+	for v := range lut {
+		if srcTotal == 0 || refTotal == 0 {
+			lut[v] = uint8(min(v, len(ref)-1))
+			continue
+		}
+		p := float64(srcCDF[v]) / srcTotal
+		for r < len(refCDF)-1 && float64(refCDF[r])/refTotal < p {
+			r++
+		} // Both cumulative histograms grow, so the search resumes where it stopped.
+		lut[v] = uint8(r)
+	}
+	return lut
+}
+
+// claheChannel applies contrast limited adaptive histogram equalization to the channel.
+// Each tile gets its own equalization, with histogram bins clipped at clipLimit times the
+// average bin height and the excess spread over all bins, and every pixel blends the
+// mappings of the four nearest tile centers.
+func claheChannel(channel [][]uint8, maxValue uint8, tileWidth, tileHeight int, clipLimit float64) {
+	height := len(channel)
+	if height == 0 {
+		return
+	}
+	width := len(channel[0])
+	tilesX := (width + tileWidth - 1) / tileWidth
+	tilesY := (height + tileHeight - 1) / tileHeight
+	bins := int(maxValue) + 1
+
+	luts := make([][][]uint8, tilesY)
+	for ty := range luts {
+		luts[ty] = make([][]uint8, tilesX)
+		for tx := range luts[ty] {
+			hist := make([]int, bins)
+			count := 0
+			for y := ty * tileHeight; y < min((ty+1)*tileHeight, height); y++ {
+				for x := tx * tileWidth; x < min((tx+1)*tileWidth, width); x++ {
+					hist[min(channel[y][x], maxValue)]++
+					count++
+				}
+			}
+			if clipLimit > 0 {
+				limit := int(math.Max(1, clipLimit*float64(count)/float64(bins)))
+				excess := 0
+				for i := range hist {
+					if hist[i] > limit {
+						excess += hist[i] - limit
+						hist[i] = limit
+					}
+				}
+				for i := range hist {
+					hist[i] += excess / bins
+				}
+				for i, residual := 0, excess%bins; i < residual; i++ {
+					hist[i*bins/residual]++
+				} // Redistribute the clipped counts evenly so the total is unchanged.
+			}
+			cdf := CumulativeHistogram(hist)
+			lut := make([]uint8, bins)
+			for v := range lut {
+				lut[v] = clampToMax(float64(cdf[v])/float64(count)*float64(maxValue), maxValue)
+			}
+			luts[ty][tx] = lut
+		}
+	}
+
+	for y := 0; y < height; y++ {
+		fy := (float64(y)+0.5)/float64(tileHeight) - 0.5 // Position in tile center units.
+		ty0 := min(max(int(math.Floor(fy)), 0), tilesY-1)
+		ty1 := min(ty0+1, tilesY-1)
+		wy := math.Min(math.Max(fy-float64(ty0), 0), 1)
+		for x := 0; x < width; x++ {
+			fx := (float64(x)+0.5)/float64(tileWidth) - 0.5
+			tx0 := min(max(int(math.Floor(fx)), 0), tilesX-1)
+			tx1 := min(tx0+1, tilesX-1)
+			wx := math.Min(math.Max(fx-float64(tx0), 0), 1)
+			v := min(channel[y][x], maxValue)
+			channel[y][x] = clampToMax(bilinear(
+				float64(luts[ty0][tx0][v]), float64(luts[ty0][tx1][v]),
+				float64(luts[ty1][tx0][v]), float64(luts[ty1][tx1][v]), wx, wy), maxValue)
+		}
+	}
+}
+
+// Histogram returns the number of pixels of the PGM image for every value from 0 to max.
+func (pgm *PGM) Histogram() []int {
+	return histogramOf(pgm.data, pgm.max)
+}
+
+// Equalize spreads the values of the PGM image evenly over [0, max] to improve its contrast.
+func (pgm *PGM) Equalize() {
+	applyLUT(pgm.data, equalizationLUT(pgm.Histogram(), pgm.max))
+}
+
+// MatchHistogram remaps the values of the PGM image so that its histogram matches the one of
+// the reference image, rescaled to the max value of pgm.
+func (pgm *PGM) MatchHistogram(ref *PGM) {
+	refHist := make([]int, int(pgm.max)+1)
+	for v, count := range ref.Histogram() {
+		refHist[int(math.Round(float64(v)*float64(pgm.max)/float64(max(ref.max, 1))))] += count
+	} // Bring the reference values to the same range first.
+	applyLUT(pgm.data, matchingLUT(pgm.Histogram(), refHist))
+}
+
+// CLAHE applies contrast limited adaptive histogram equalization to the PGM image, working on
+// tiles of tileWidth x tileHeight pixels. clipLimit bounds each histogram bin to that multiple
+// of the average bin height, limiting noise amplification; zero disables clipping.
+func (pgm *PGM) CLAHE(tileWidth, tileHeight int, clipLimit float64) {
+	if tileWidth <= 0 || tileHeight <= 0 {
+		return
+	}
+	claheChannel(pgm.data, pgm.max, tileWidth, tileHeight, clipLimit)
+}
+
+// Histogram returns the number of pixels of the PPM image for every value from 0 to max, for
+// the red, green and blue channels.
+func (ppm *PPM) Histogram() [3][]int {
+	var hists [3][]int
+	channels := ppm.channels()
+	for c := range hists {
+		hists[c] = histogramOf(channels[c], ppm.max)
+	}
+	return hists
+}
+
+// Equalize equalizes each channel of the PPM image independently.
+func (ppm *PPM) Equalize() {
+	channels := ppm.channels()
+	for c := range channels {
+		applyLUT(channels[c], equalizationLUT(histogramOf(channels[c], ppm.max), ppm.max))
+	}
+	ppm.setChannels(channels)
+}
+
+// MatchHistogram remaps each channel of the PPM image so that its histogram matches the same
+// channel of the reference image.
+func (ppm *PPM) MatchHistogram(ref *PPM) {
+	channels := ppm.channels()
+	refHists := ref.Histogram()
+	for c := range channels {
+		refHist := make([]int, int(ppm.max)+1)
+		for v, count := range refHists[c] {
+			refHist[int(math.Round(float64(v)*float64(ppm.max)/float64(max(ref.max, 1))))] += count
+		}
+		applyLUT(channels[c], matchingLUT(histogramOf(channels[c], ppm.max), refHist))
+	}
+	ppm.setChannels(channels)
+}
+
+// CLAHE applies contrast limited adaptive histogram equalization to each channel of the PPM
+// image, see PGM.CLAHE.
+func (ppm *PPM) CLAHE(tileWidth, tileHeight int, clipLimit float64) {
+	if tileWidth <= 0 || tileHeight <= 0 {
+		return
+	}
+	channels := ppm.channels()
+	for c := range channels {
+		claheChannel(channels[c], ppm.max, tileWidth, tileHeight, clipLimit)
+	}
+	ppm.setChannels(channels)
+}
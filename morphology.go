@@ -0,0 +1,156 @@
+package Netpbm
+
+// StructuringElement is the neighborhood shape used by morphological operators, given as the
+// offsets of its pixels relative to its origin.
+type StructuringElement struct {
+	Offsets []Point
+	Heights []int // Height of each offset for non-flat grayscale morphology, nil for a flat element.
+}
+
+// SquareElement returns a (2*radius+1)x(2*radius+1) square centered on the origin.
+func SquareElement(radius int) StructuringElement {
+	var se StructuringElement
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			se.Offsets = append(se.Offsets, Point{dx, dy})
+		}
+	}
+	return se
+}
+
+// CrossElement returns a plus-shaped element whose arms are radius pixels long.
+func CrossElement(radius int) StructuringElement {
+	se := StructuringElement{Offsets: []Point{{0, 0}}}
+	for d := 1; d <= radius; d++ {
+		se.Offsets = append(se.Offsets, Point{d, 0}, Point{-d, 0}, Point{0, d}, Point{0, -d})
+	}
+	return se
+}
+
+// DiskElement returns a disk of the given radius centered on the origin.
+func DiskElement(radius int) StructuringElement {
+	var se StructuringElement
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			if dx*dx+dy*dy <= radius*radius {
+				se.Offsets = append(se.Offsets, Point{dx, dy})
+			}
+		}
+	}
+	return se
+}
+
+// ElementFromPBM returns an element made of the true pixels of the PBM image, with its origin
+// at the center of the image.
+func ElementFromPBM(pbm *PBM) StructuringElement {
+	var se StructuringElement
+	cx, cy := pbm.width/2, pbm.height/2
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			if pbm.data[y][x] {
+				se.Offsets = append(se.Offsets, Point{x - cx, y - cy})
+			}
+		}
+	}
+	return se
+}
+
+// Erode returns a new PBM image where a pixel is true only if every pixel covered by the
+// element placed on it is true. Pixels outside of the image don't erode the shapes.
+func (pbm *PBM) Erode(se StructuringElement) *PBM {
+	out := newPBM(pbm.width, pbm.height, pbm.magicNumber)
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			fits := true
+			for _, o := range se.Offsets {
+				nx, ny := x+o.X, y+o.Y
+				if nx >= 0 && ny >= 0 && nx < pbm.width && ny < pbm.height && !pbm.data[ny][nx] {
+					fits = false
+					break
+				}
+			}
+			out.data[y][x] = fits
+		}
+	}
+	return out
+}
+
+// Dilate returns a new PBM image where a pixel is true if the element reflected and placed on
+// it covers at least one true pixel.
+func (pbm *PBM) Dilate(se StructuringElement) *PBM {
+	out := newPBM(pbm.width, pbm.height, pbm.magicNumber)
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			if !pbm.data[y][x] {
+				continue
+			}
+			for _, o := range se.Offsets {
+				out.Set(x+o.X, y+o.Y, true) // Stamp the element on every true pixel.
+			}
+		}
+	}
+	return out
+}
+
+// Open returns the erosion of the PBM image followed by a dilation, which removes the shapes
+// and protrusions smaller than the element.
+func (pbm *PBM) Open(se StructuringElement) *PBM {
+	return pbm.Erode(se).Dilate(se)
+}
+
+// Close returns the dilation of the PBM image followed by an erosion, which fills the holes
+// and gaps smaller than the element.
+func (pbm *PBM) Close(se StructuringElement) *PBM {
+	return pbm.Dilate(se).Erode(se)
+}
+
+// TopHat returns the pixels of the PBM image removed by an opening (white top-hat).
+func (pbm *PBM) TopHat(se StructuringElement) *PBM {
+	return pbm.difference(pbm.Open(se))
+}
+
+// BlackHat returns the pixels added to the PBM image by a closing (black top-hat).
+func (pbm *PBM) BlackHat(se StructuringElement) *PBM {
+	return pbm.Close(se).difference(pbm)
+}
+
+// MorphologicalGradient returns the dilation minus the erosion of the PBM image, which outlines
+// the shapes.
+func (pbm *PBM) MorphologicalGradient(se StructuringElement) *PBM {
+	return pbm.Dilate(se).difference(pbm.Erode(se))
+}
+
+// HitOrMiss returns a new PBM image where a pixel is true if the hit element fits inside the
+// shapes and the miss element fits inside the background when both are placed on it. It finds
+// patterns such as corners, end points or isolated pixels.
+func (pbm *PBM) HitOrMiss(hit, miss StructuringElement) *PBM {
+	out := newPBM(pbm.width, pbm.height, pbm.magicNumber)
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			out.data[y][x] = pbm.matches(x, y, hit, true) && pbm.matches(x, y, miss, false)
+		}
+	}
+	return out
+}
+
+// matches reports whether every pixel covered by the element placed on (x, y) has the given
+// value. Pixels outside of the image are considered false.
+func (pbm *PBM) matches(x, y int, se StructuringElement, value bool) bool {
+	for _, o := range se.Offsets {
+		if pbm.At(x+o.X, y+o.Y) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// difference returns a new PBM image with the pixels true in pbm but not in other.
+func (pbm *PBM) difference(other *PBM) *PBM {
+	out := newPBM(pbm.width, pbm.height, pbm.magicNumber)
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			out.data[y][x] = pbm.data[y][x] && !other.data[y][x]
+		}
+	}
+	return out
+}
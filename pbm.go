@@ -15,6 +15,15 @@ type PBM struct {
 	magicNumber   string
 }
 
+// newPBM allocates a blank PBM image with the given dimensions.
+func newPBM(width, height int, magicNumber string) *PBM {
+	data := make([][]bool, height)
+	for y := range data {
+		data[y] = make([]bool, width)
+	}
+	return &PBM{data, width, height, magicNumber}
+}
+
 // ReadPBM reads a PBM image from a file and returns a struct that represents the image.
 func ReadPBM(filename string) (*PBM, error) {
 	file, err := os.Open(filename)
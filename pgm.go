@@ -14,6 +14,15 @@ type PGM struct {
 	max           uint8
 }
 
+// newPGM allocates a blank PGM image with the given dimensions and max value.
+func newPGM(width, height int, magicNumber string, max uint8) *PGM {
+	data := make([][]uint8, height)
+	for y := range data {
+		data[y] = make([]uint8, width)
+	}
+	return &PGM{data, width, height, magicNumber, max}
+}
+
 func ReadPGM(filename string) (*PGM, error) {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -0,0 +1,108 @@
+package Netpbm
+
+import (
+	"math"
+	"sort"
+)
+
+// FillRule decides which regions enclosed by a polygon's edges are inside it.
+type FillRule int
+
+const (
+	FillNonZero FillRule = iota // Inside where the edges wind around the point a nonzero number of times.
+	FillEvenOdd                 // Inside where a ray from the point crosses an odd number of edges.
+)
+
+// polygonEdge is an entry of the edge table: a non-horizontal edge going down from yTop to
+// yBottom, x being its abscissa on the current scanline.
+type polygonEdge struct {
+	yTop, yBottom float64
+	x, slope      float64 // Abscissa on the scanline and its change per scanline.
+	winding       int     // +1 for edges going down, -1 for edges going up.
+	last          int     // First scanline no longer crossing the edge.
+}
+
+// scanPolygon rasterizes the rings with an edge table and an active edge list, calling span
+// with the start and end abscissas of every inside interval of each scanline. Scanline r
+// samples y = y0 + r*step for r in [0, rows). Edges are half-open in y so that a vertex shared
+// by two edges is crossed once. Each ring is closed implicitly; holes are rings inside others,
+// wound in the opposite direction for FillNonZero.
+func scanPolygon(rings [][]vec, rule FillRule, y0, step float64, rows int, span func(row int, x0, x1 float64)) {
+	table := make([][]*polygonEdge, rows) // Edges bucketed by the first scanline crossing them.
+	for _, ring := range rings {
+		for i, a := range ring {
+			b := ring[(i+1)%len(ring)]
+			if a.y == b.y {
+				continue
+			} // Horizontal edges are never crossed by a scanline.
+			e := &polygonEdge{winding: 1}
+			if a.y > b.y {
+				a, b, e.winding = b, a, -1
+			}
+			e.yTop, e.yBottom, e.slope = a.y, b.y, (b.x-a.x)/(b.y-a.y)
+			first := max(int(math.Ceil((a.y-y0)/step)), 0)
+			e.last = min(int(math.Ceil((b.y-y0)/step)), rows)
+			if first >= e.last {
+				continue
+			}
+			e.x = a.x + (y0+float64(first)*step-a.y)*e.slope
+			e.slope *= step
+			table[first] = append(table[first], e)
+		}
+	}
+
+	var active []*polygonEdge
+	for r := 0; r < rows; r++ {
+		kept := active[:0]
+		for _, e := range active {
+			if e.last > r {
+				e.x += e.slope
+				kept = append(kept, e)
+			}
+		} // Drop the edges the scanline has passed and step the others.
+		active = append(kept, table[r]...)
+		sort.Slice(active, func(i, j int) bool { return active[i].x < active[j].x })
+
+		winding := 0
+		for i, e := range active {
+			if winding != 0 {
+				span(r, active[i-1].x, e.x)
+			} // The interval since the previous crossing is inside.
+			if rule == FillEvenOdd {
+				winding ^= 1
+			} else {
+				winding += e.winding
+			}
+		}
+	}
+}
+
+// toRings converts polygons given as points to rings of real coordinates.
+func toRings(polygons [][]Point) [][]vec {
+	rings := make([][]vec, 0, len(polygons))
+	for _, points := range polygons {
+		if len(points) < 3 {
+			continue
+		}
+		ring := make([]vec, len(points))
+		for i, p := range points {
+			ring[i] = toVec(p)
+		}
+		rings = append(rings, ring)
+	}
+	return rings
+}
+
+// DrawFilledPolygons fills the region enclosed by several polygons with the fill rule, which
+// allows drawing shapes with holes, then draws their outlines. A pixel is filled when its center
+// is inside; the result doesn't depend on the content of the image.
+func (ppm *PPM) DrawFilledPolygons(polygons [][]Point, color Pixel, rule FillRule) {
+	scanPolygon(toRings(polygons), rule, 0, 1, ppm.height, func(y int, x0, x1 float64) {
+		for x := max(int(math.Ceil(x0)), 0); x < min(int(math.Ceil(x1)), ppm.width); x++ {
+			ppm.data[y][x] = color
+		} // Pixels whose center is in [x0, x1).
+	})
+	for _, points := range polygons {
+		ppm.DrawPolygon(points, color)
+	} // The outline covers the edge pixels the same way DrawPolygon does.
+}
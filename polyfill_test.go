@@ -0,0 +1,85 @@
+package Netpbm
+
+import "testing"
+
+// square returns the corners of the square [x0, x1] x [y0, y1], clockwise on screen when
+// clockwise is true.
+func square(x0, y0, x1, y1 int, clockwise bool) []Point {
+	if clockwise {
+		return []Point{{x0, y0}, {x1, y0}, {x1, y1}, {x0, y1}}
+	}
+	return []Point{{x0, y0}, {x0, y1}, {x1, y1}, {x1, y0}}
+}
+
+// TestDrawFilledPolygonsHole checks that a hole is left empty with both fill rules, given the
+// winding each rule needs, and that the outer ring is filled.
+func TestDrawFilledPolygonsHole(t *testing.T) {
+	white := Pixel{255, 255, 255}
+	for _, tc := range []struct {
+		name string
+		rule FillRule
+		hole []Point
+	}{
+		{"nonzero", FillNonZero, square(7, 5, 16, 10, false)},
+		{"even-odd", FillEvenOdd, square(7, 5, 16, 10, true)},
+	} {
+		ppm := newPPM(24, 16, "P3", 255)
+		ppm.DrawFilledPolygons([][]Point{square(2, 2, 21, 13, true), tc.hole}, white, tc.rule)
+		for _, p := range []Point{{2, 2}, {21, 13}, {4, 7}, {19, 7}, {10, 3}, {10, 12}} {
+			if ppm.data[p.Y][p.X] != white {
+				t.Errorf("%s: pixel %v isn't filled", tc.name, p)
+			}
+		}
+		for _, p := range []Point{{8, 6}, {12, 8}, {15, 9}, {1, 1}, {22, 14}} {
+			if ppm.data[p.Y][p.X] != (Pixel{}) {
+				t.Errorf("%s: pixel %v is filled", tc.name, p)
+			}
+		}
+	}
+}
+
+// TestDrawFilledPolygonIgnoresCanvas checks that pixels already having the fill color don't
+// change which pixels are filled, and that a concave polygon keeps its notch empty.
+func TestDrawFilledPolygonIgnoresCanvas(t *testing.T) {
+	white := Pixel{255, 255, 255}
+	ppm := newPPM(24, 16, "P3", 255)
+	ppm.data[3][22] = white
+	ppm.DrawFilledPolygon([]Point{{2, 2}, {8, 2}, {8, 10}, {14, 10}, {14, 2}, {20, 2}, {20, 13}, {2, 13}}, white)
+	for x := 9; x <= 13; x++ {
+		for y := 0; y < 10; y++ {
+			if ppm.data[y][x] == white {
+				t.Fatalf("notch pixel (%d, %d) is filled", x, y)
+			}
+		}
+	}
+	if ppm.data[3][21] == white {
+		t.Error("the fill leaked towards a pre-existing pixel of the fill color")
+	}
+}
+
+// TestPolygonCoverageArea checks that the anti-aliased coverage of a shape with a hole sums to
+// its exact area.
+func TestPolygonCoverageArea(t *testing.T) {
+	coverage := polygonCoverage([][]Point{square(2, 2, 21, 13, true), square(7, 5, 16, 10, true)}, FillEvenOdd, 24, 16)
+	sum := 0.0
+	for _, row := range coverage {
+		for _, v := range row {
+			sum += v
+		}
+	}
+	if want := 19.0*11 - 9*5; sum < want-1e-6 || sum > want+1e-6 {
+		t.Errorf("covered area = %v, want %v", sum, want)
+	}
+}
+
+// TestAntialiasedFilledPolygonNonZero checks that the anti-aliased fill uses the nonzero rule
+// by default, like DrawFilledPolygon, filling the center of a self-intersecting pentagram.
+func TestAntialiasedFilledPolygonNonZero(t *testing.T) {
+	star := []Point{{10, 0}, {16, 19}, {0, 7}, {20, 7}, {4, 19}}
+	ppm, aa := newPPM(21, 21, "P3", 255), newPPM(21, 21, "P3", 255)
+	ppm.DrawFilledPolygon(star, Pixel{255, 255, 255})
+	aa.DrawAntialiasedFilledPolygon(star, Pixel{255, 255, 255})
+	if ppm.data[11][10] != (Pixel{255, 255, 255}) || aa.data[11][10] != (Pixel{255, 255, 255}) {
+		t.Errorf("center = %v and %v, want both filled", ppm.data[11][10], aa.data[11][10])
+	}
+}
@@ -21,6 +21,15 @@ type PPM struct {
 	max           uint8
 }
 
+// newPPM allocates a blank PPM image with the given dimensions and max value.
+func newPPM(width, height int, magicNumber string, max uint8) *PPM {
+	data := make([][]Pixel, height)
+	for y := range data {
+		data[y] = make([]Pixel, width)
+	}
+	return &PPM{data, width, height, magicNumber, max}
+}
+
 // ReadPPM reads a PPM image from a file and returns a struct that represents the image.
 func ReadPPM(filename string) (*PPM, error) {
 	file, err := os.Open(filename)
@@ -319,12 +328,12 @@ func (ppm *PPM) DrawLine(p1, p2 Point, color Pixel) { // Based on Bresenham's li
 	for x := p1.X; x <= p2.X; x++ { // Iterate over x-coordinates.
 		if steep {
 			// Plot the point with swapped coordinates for steep lines.
-			if y >= 0 && y < len(ppm.data) && x >= 0 && x < len(ppm.data[y]) {
+			if x >= 0 && x < len(ppm.data) && y >= 0 && y < len(ppm.data[x]) {
 				ppm.Set(y, x, color)
 			}
 		} else {
 			// Plot the point with original coordinates for non-steep lines.
-			if x >= 0 && x < len(ppm.data) && y >= 0 && y < len(ppm.data[x]) {
+			if y >= 0 && y < len(ppm.data) && x >= 0 && x < len(ppm.data[y]) {
 				ppm.Set(x, y, color)
 			}
 		}
@@ -466,29 +475,9 @@ func (ppm *PPM) DrawPolygon(points []Point, color Pixel) {
 	ppm.DrawLine(points[numPoints-1], points[0], color)
 }
 
-// DrawFilledPolygon draws a filled polygon.
+// DrawFilledPolygon draws a filled polygon with the nonzero rule, see DrawFilledPolygons.
 func (ppm *PPM) DrawFilledPolygon(points []Point, color Pixel) {
-
-	ppm.DrawPolygon(points, color) // First, draw the outline of the polygon.
-
-	for i := 0; i < ppm.height; i++ { // Iterate over each row of the image.
-		var positions []int   // To store the x-positions where the polygon's edge is found.
-		var number_points int // Count of points found on this row.
-
-		for j := 0; j < ppm.width; j++ { // Check each pixel in the row.
-			if ppm.data[i][j] == color {
-				number_points += 1
-				positions = append(positions, j)
-			} // If a pixel is part of the polygon's edge, record its position.
-		}
-
-		// If more than one edge point is found on the row, fill the space between them.
-		if number_points > 1 {
-			for k := positions[0] + 1; k < positions[len(positions)-1]; k++ {
-				ppm.data[i][k] = color // Fill the pixels between the first and last edge points.
-			}
-		}
-	}
+	ppm.DrawFilledPolygons([][]Point{points}, color, FillNonZero)
 }
 
 func (ppm *PPM) DrawKochSnowflake(n int, start Point, width int, color Pixel) { //It doesn't work but i let it there since it was hard to come up with it.
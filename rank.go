@@ -0,0 +1,138 @@
+package Netpbm
+
+// rankFilterChannel replaces every sample of the channel with the value of the given rank in
+// the (2*radius+1)^2 window around it, rank going from 0 (minimum) to 1 (maximum). Pixels
+// outside of the image are skipped, so windows near the borders are smaller.
+//
+// The window histogram is updated incrementally (Huang's algorithm): moving one pixel to the
+// right removes the leftmost column and adds a new column, so the cost per pixel grows with
+// the radius instead of its square.
+func rankFilterChannel(channel [][]uint8, radius int, rank float64) [][]uint8 {
+	height := len(channel)
+	if height == 0 || radius <= 0 {
+		return channel
+	}
+	width := len(channel[0])
+	out := make([][]uint8, height)
+	var hist [256]int
+	for y := 0; y < height; y++ {
+		out[y] = make([]uint8, width)
+		y0, y1 := max(y-radius, 0), min(y+radius, height-1)
+		hist = [256]int{}
+		count := 0
+		for x := 0; x <= min(radius, width-1); x++ {
+			for j := y0; j <= y1; j++ {
+				hist[channel[j][x]]++
+				count++
+			}
+		} // Build the histogram of the first window of the row.
+
+		for x := 0; x < width; x++ {
+			target := int(rank*float64(count-1) + 0.5) // Index of the wanted value in the sorted window.
+			seen := 0
+			for v := 0; v < 256; v++ {
+				seen += hist[v]
+				if seen > target {
+					out[y][x] = uint8(v)
+					break
+				}
+			}
+
+			if leaving := x - radius; leaving >= 0 {
+				for j := y0; j <= y1; j++ {
+					hist[channel[j][leaving]]--
+					count--
+				}
+			} // Remove the column that leaves the window.
+			if entering := x + radius + 1; entering < width {
+				for j := y0; j <= y1; j++ {
+					hist[channel[j][entering]]++
+					count++
+				}
+			} // Add the column that enters the window.
+		}
+	}
+	return out
+}
+
+// clampRank keeps a percentile inside [0, 100] and converts it to a rank in [0, 1].
+func clampRank(percentile float64) float64 {
+	return min(max(percentile, 0), 100) / 100
+}
+
+// RankFilter replaces each pixel of the PGM image with the given percentile (0 to 100) of the
+// (2*radius+1)^2 window around it.
+func (pgm *PGM) RankFilter(radius int, percentile float64) {
+	out := rankFilterChannel(pgm.data, radius, clampRank(percentile))
+	for y := range pgm.data {
+		copy(pgm.data[y], out[y])
+	}
+}
+
+// MedianFilter replaces each pixel of the PGM image with the median of the window around it,
+// which removes salt-and-pepper noise while keeping edges sharp.
+func (pgm *PGM) MedianFilter(radius int) {
+	pgm.RankFilter(radius, 50)
+}
+
+// MinFilter replaces each pixel of the PGM image with the minimum of the window around it.
+func (pgm *PGM) MinFilter(radius int) {
+	pgm.RankFilter(radius, 0)
+}
+
+// MaxFilter replaces each pixel of the PGM image with the maximum of the window around it.
+func (pgm *PGM) MaxFilter(radius int) {
+	pgm.RankFilter(radius, 100)
+}
+
+// channels returns the red, green and blue channels of the PPM image.
+func (ppm *PPM) channels() [3][][]uint8 {
+	var channels [3][][]uint8
+	for c := range channels {
+		channels[c] = make([][]uint8, ppm.height)
+		for y := range channels[c] {
+			channels[c][y] = make([]uint8, ppm.width)
+		}
+	}
+	for y := 0; y < ppm.height; y++ {
+		for x := 0; x < ppm.width; x++ {
+			p := ppm.data[y][x]
+			channels[0][y][x], channels[1][y][x], channels[2][y][x] = p.R, p.G, p.B
+		}
+	}
+	return channels
+}
+
+// setChannels stores the red, green and blue channels into the PPM image.
+func (ppm *PPM) setChannels(channels [3][][]uint8) {
+	for y := 0; y < ppm.height; y++ {
+		for x := 0; x < ppm.width; x++ {
+			ppm.data[y][x] = Pixel{channels[0][y][x], channels[1][y][x], channels[2][y][x]}
+		}
+	}
+}
+
+// RankFilter replaces each channel of every pixel of the PPM image with the given percentile
+// (0 to 100) of that channel over the (2*radius+1)^2 window around it.
+func (ppm *PPM) RankFilter(radius int, percentile float64) {
+	channels := ppm.channels()
+	for c := range channels {
+		channels[c] = rankFilterChannel(channels[c], radius, clampRank(percentile))
+	}
+	ppm.setChannels(channels)
+}
+
+// MedianFilter applies a per-channel median filter to the PPM image.
+func (ppm *PPM) MedianFilter(radius int) {
+	ppm.RankFilter(radius, 50)
+}
+
+// MinFilter applies a per-channel minimum filter to the PPM image.
+func (ppm *PPM) MinFilter(radius int) {
+	ppm.RankFilter(radius, 0)
+}
+
+// MaxFilter applies a per-channel maximum filter to the PPM image.
+func (ppm *PPM) MaxFilter(radius int) {
+	ppm.RankFilter(radius, 100)
+}
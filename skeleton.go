@@ -0,0 +1,179 @@
+package Netpbm
+
+import (
+	"math"
+	"sort"
+)
+
+// ThinningAlgorithm selects the algorithm used by PBM.Thin.
+type ThinningAlgorithm int
+
+const (
+	ThinningZhangSuen ThinningAlgorithm = iota // Zhang-Suen two sub-iteration thinning.
+	ThinningGuoHall                            // Guo-Hall thinning, keeps diagonal lines slimmer.
+)
+
+// neighbors returns the 8 neighbors of (x, y) clockwise starting from the north one, as
+// P2, P3, ..., P9 in the usual thinning notation. Pixels outside of the image are false.
+func (pbm *PBM) neighbors(x, y int) [8]bool {
+	return [8]bool{
+		pbm.At(x, y-1), pbm.At(x+1, y-1), pbm.At(x+1, y), pbm.At(x+1, y+1),
+		pbm.At(x, y+1), pbm.At(x-1, y+1), pbm.At(x-1, y), pbm.At(x-1, y-1),
+	}
+}
+
+// b2i converts a boolean to 0 or 1.
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// zhangSuenRemovable reports whether the pixel with neighbors p can be removed in the given
+// sub-iteration of Zhang-Suen thinning.
+func zhangSuenRemovable(p [8]bool, iteration int) bool {
+	count, transitions := 0, 0
+	for i := 0; i < 8; i++ {
+		count += b2i(p[i])
+		if !p[i] && p[(i+1)%8] {
+			transitions++
+		}
+	} // Number of true neighbors and of false to true transitions around the pixel.
+	if count < 2 || count > 6 || transitions != 1 {
+		return false
+	}
+	p2, p4, p6, p8 := p[0], p[2], p[4], p[6]
+	if iteration == 0 {
+		return !(p2 && p4 && p6) && !(p4 && p6 && p8) // Remove south-east boundary pixels.
+	}
+	return !(p2 && p4 && p8) && !(p2 && p6 && p8) // Remove north-west boundary pixels.
+}
+
+// guoHallRemovable reports whether the pixel with neighbors p can be removed in the given
+// sub-iteration of Guo-Hall thinning.
+func guoHallRemovable(p [8]bool, iteration int) bool {
+	p2, p3, p4, p5, p6, p7, p8, p9 := p[0], p[1], p[2], p[3], p[4], p[5], p[6], p[7]
+	c := b2i(!p2 && (p3 || p4)) + b2i(!p4 && (p5 || p6)) + b2i(!p6 && (p7 || p8)) + b2i(!p8 && (p9 || p2))
+	n1 := b2i(p9 || p2) + b2i(p3 || p4) + b2i(p5 || p6) + b2i(p7 || p8)
+	n2 := b2i(p2 || p3) + b2i(p4 || p5) + b2i(p6 || p7) + b2i(p8 || p9)
+	n := min(n1, n2)
+	var m bool
+	if iteration == 0 {
+		m = (p6 || p7 || !p9) && p8
+	} else {
+		m = (p2 || p3 || !p5) && p4
+	}
+	return c == 1 && n >= 2 && n <= 3 && !m
+}
+
+// Thin returns a new PBM image with the true shapes reduced to one pixel wide skeletons that
+// keep their topology, using the given algorithm.
+func (pbm *PBM) Thin(algorithm ThinningAlgorithm) *PBM {
+	removable := zhangSuenRemovable
+	if algorithm == ThinningGuoHall {
+		removable = guoHallRemovable
+	}
+	out := pbm.Crop(Rect{0, 0, pbm.width, pbm.height})
+	var marked []Point
+	for changed := true; changed; {
+		changed = false
+		for iteration := 0; iteration < 2; iteration++ {
+			marked = marked[:0]
+			for y := 0; y < out.height; y++ {
+				for x := 0; x < out.width; x++ {
+					if out.data[y][x] && removable(out.neighbors(x, y), iteration) {
+						marked = append(marked, Point{x, y})
+					}
+				}
+			} // Pixels are marked first and removed together so the scan order doesn't matter.
+			for _, p := range marked {
+				out.data[p.Y][p.X] = false
+			}
+			changed = changed || len(marked) > 0
+		}
+	}
+	return out
+}
+
+// isSimple reports whether removing the pixel with neighbors p keeps the topology of the
+// shapes, using the 8-connectivity Yokoi connectivity number.
+func isSimple(p [8]bool) bool {
+	e := [8]bool{p[2], p[1], p[0], p[7], p[6], p[5], p[4], p[3]} // Neighbors counterclockwise from east.
+	n := 0
+	for k := 0; k < 8; k += 2 {
+		xk, xk1, xk2 := !e[k], !e[(k+1)%8], !e[(k+2)%8] // Complemented values for 8-connectivity.
+		n += b2i(xk) - b2i(xk && xk1 && xk2)
+	}
+	return n == 1
+}
+
+// MedialAxis returns the medial axis of the true shapes of the PBM image as a new PBM image,
+// together with the Euclidean distance from each axis pixel to the nearest false pixel (zero
+// elsewhere). Pixels are peeled in order of increasing distance as long as this keeps the
+// topology and doesn't shorten branches, so the axis stays centered in the shapes.
+func (pbm *PBM) MedialAxis() (*PBM, [][]float64) {
+	sq := squaredEDT(pbm.width+2, pbm.height+2, func(x, y int) bool {
+		return !pbm.At(x-1, y-1)
+	}) // Measure on a one pixel false border so that the image edges count as background.
+
+	out := pbm.Crop(Rect{0, 0, pbm.width, pbm.height})
+	var order []Point
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			if pbm.data[y][x] {
+				order = append(order, Point{x, y})
+			}
+		}
+	}
+	level := func(p Point) float64 { return sq[p.Y+1][p.X+1] }
+	sort.SliceStable(order, func(i, j int) bool { return level(order[i]) < level(order[j]) })
+
+	// Pixels at the same distance are peeled in parallel, one border direction at a time
+	// (north, south, east, west), so that the result doesn't depend on the scan order. Removing
+	// simple non-end border pixels of a single direction at once keeps the topology.
+	for changed := true; changed; {
+		changed = false
+		for start := 0; start < len(order); {
+			end := start
+			for end < len(order) && level(order[end]) == level(order[start]) {
+				end++
+			}
+			for peeled := true; peeled; {
+				peeled = false
+				for _, side := range []int{0, 4, 2, 6} { // Indices of the N, S, E and W neighbors.
+					var removable []Point
+					for _, p := range order[start:end] {
+						if !out.data[p.Y][p.X] {
+							continue
+						}
+						n := out.neighbors(p.X, p.Y)
+						count := 0
+						for _, v := range n {
+							count += b2i(v)
+						}
+						if !n[side] && count > 1 && isSimple(n) {
+							removable = append(removable, p)
+						} // End points (a single neighbor) are kept so that branches aren't eaten away.
+					}
+					for _, p := range removable {
+						out.data[p.Y][p.X] = false
+					}
+					peeled = peeled || len(removable) > 0
+				}
+				changed = changed || peeled
+			}
+			start = end
+		}
+	}
+
+	distance := newPlane(pbm.width, pbm.height)
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			if out.data[y][x] {
+				distance[y][x] = math.Sqrt(sq[y+1][x+1])
+			}
+		}
+	}
+	return out, distance
+}
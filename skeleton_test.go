@@ -0,0 +1,39 @@
+package Netpbm
+
+import "testing"
+
+// TestMedialAxisSymmetric checks that the medial axis of a filled rectangle is mirror symmetric,
+// so that no corner is treated differently because of the scan order. A side with an even
+// number of pixels has no center row, so the axis along it can only be checked left-right.
+func TestMedialAxisSymmetric(t *testing.T) {
+	for _, tc := range []struct {
+		width, height int
+		vertical      bool // Whether the axis must also be symmetric top-bottom.
+	}{
+		{12, 5, true},
+		{9, 9, true},
+		{14, 8, false},
+	} {
+		w, h := tc.width+2, tc.height+2
+		pbm := newPBM(w, h, "P1")
+		for y := 1; y <= tc.height; y++ {
+			for x := 1; x <= tc.width; x++ {
+				pbm.data[y][x] = true
+			}
+		}
+		axis, _ := pbm.MedialAxis()
+		count := 0
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				v := axis.data[y][x]
+				count += b2i(v)
+				if v != axis.data[y][w-1-x] || tc.vertical && v != axis.data[h-1-y][x] {
+					t.Fatalf("%dx%d: axis isn't symmetric at (%d, %d)", tc.width, tc.height, x, y)
+				}
+			}
+		}
+		if count == 0 {
+			t.Errorf("%dx%d: empty axis", tc.width, tc.height)
+		}
+	}
+}
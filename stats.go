@@ -0,0 +1,169 @@
+package Netpbm
+
+import "math"
+
+// ChannelStats summarizes the values of one channel of an image.
+type ChannelStats struct {
+	Min, Max uint8
+	Mean     float64
+	StdDev   float64 // Population standard deviation.
+	Unique   int     // Number of distinct values.
+}
+
+// PPMStats summarizes a PPM image channel by channel (red, green, blue).
+type PPMStats struct {
+	Channels     [3]ChannelStats
+	UniqueColors int // Number of distinct pixels.
+}
+
+// PBMStats summarizes a PBM image.
+type PBMStats struct {
+	Foreground int     // Number of true (black) pixels.
+	Ratio      float64 // Foreground pixels over all pixels, 0 for an empty image.
+}
+
+// channelStats computes the statistics of a channel from its histogram.
+func channelStats(hist []int) ChannelStats {
+	var s ChannelStats
+	n, sum, sumSquares := 0, 0.0, 0.0
+	for v, count := range hist {
+		if count == 0 {
+			continue
+		}
+		if s.Unique == 0 {
+			s.Min = uint8(v)
+		}
+		s.Max = uint8(v)
+		s.Unique++
+		n += count
+		sum += float64(v * count)
+		sumSquares += float64(v * v * count)
+	}
+	if n > 0 {
+		s.Mean = sum / float64(n)
+		s.StdDev = math.Sqrt(math.Max(sumSquares/float64(n)-s.Mean*s.Mean, 0))
+	}
+	return s
+}
+
+// Stats returns the statistics of the pixel values of the PGM image.
+func (pgm *PGM) Stats() ChannelStats {
+	return channelStats(histogramOf(pgm.data, 255)) // Full range, values above max are counted too.
+}
+
+// Stats returns the statistics of each channel of the PPM image and its number of colors.
+func (ppm *PPM) Stats() PPMStats {
+	var s PPMStats
+	for c, channel := range ppm.channels() {
+		s.Channels[c] = channelStats(histogramOf(channel, 255))
+	}
+	colors := make(map[Pixel]struct{})
+	for y := 0; y < ppm.height; y++ {
+		for x := 0; x < ppm.width; x++ {
+			colors[ppm.data[y][x]] = struct{}{}
+		}
+	}
+	s.UniqueColors = len(colors)
+	return s
+}
+
+// Stats returns the number and proportion of foreground pixels of the PBM image.
+func (pbm *PBM) Stats() PBMStats {
+	var s PBMStats
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			if pbm.data[y][x] {
+				s.Foreground++
+			}
+		}
+	}
+	if pbm.width > 0 && pbm.height > 0 {
+		s.Ratio = float64(s.Foreground) / float64(pbm.width*pbm.height)
+	}
+	return s
+}
+
+// contentBounds returns the smallest rectangle holding every pixel of a width x height image
+// that isn't background. The boolean is false when the whole image is background.
+func contentBounds(width, height int, isBackground func(x, y int) bool) (Rect, bool) {
+	x0, y0, x1, y1 := width, height, -1, -1
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if !isBackground(x, y) {
+				x0, y0, x1, y1 = min(x0, x), min(y0, y), max(x1, x), max(y1, y)
+			}
+		}
+	}
+	if x1 < 0 {
+		return Rect{}, false
+	}
+	return Rect{x0, y0, x1 - x0 + 1, y1 - y0 + 1}, true
+}
+
+// BoundingBox returns the smallest rectangle holding every pixel that differs from the
+// top-left pixel, taken as the border color. The boolean is false when the image is uniform.
+func (pbm *PBM) BoundingBox() (Rect, bool) {
+	if pbm.width == 0 || pbm.height == 0 {
+		return Rect{}, false
+	}
+	background := pbm.data[0][0]
+	return contentBounds(pbm.width, pbm.height, func(x, y int) bool {
+		return pbm.data[y][x] == background
+	})
+}
+
+// Trim returns a new PBM image with the uniform borders cropped away (auto-crop). A uniform
+// image is returned unchanged, as a copy.
+func (pbm *PBM) Trim() *PBM {
+	r, ok := pbm.BoundingBox()
+	if !ok {
+		r = Rect{0, 0, pbm.width, pbm.height}
+	} // Cropping everything would give a 0x0 image, which can't be saved and read back.
+	return pbm.Crop(r)
+}
+
+// BoundingBox returns the smallest rectangle holding every pixel that differs from the
+// top-left pixel by more than tolerance. The boolean is false when the image is uniform.
+func (pgm *PGM) BoundingBox(tolerance uint8) (Rect, bool) {
+	if pgm.width == 0 || pgm.height == 0 {
+		return Rect{}, false
+	}
+	background := pgm.data[0][0]
+	return contentBounds(pgm.width, pgm.height, func(x, y int) bool {
+		return withinTolerance(pgm.data[y][x], background, tolerance)
+	})
+}
+
+// Trim returns a new PGM image with the borders within tolerance of the top-left pixel
+// cropped away. A uniform image is returned unchanged, as a copy.
+func (pgm *PGM) Trim(tolerance uint8) *PGM {
+	r, ok := pgm.BoundingBox(tolerance)
+	if !ok {
+		r = Rect{0, 0, pgm.width, pgm.height}
+	} // Cropping everything would give a 0x0 image, which can't be saved and read back.
+	return pgm.Crop(r)
+}
+
+// BoundingBox returns the smallest rectangle holding every pixel that differs from the
+// top-left pixel by more than tolerance on any channel. The boolean is false when the image
+// is uniform.
+func (ppm *PPM) BoundingBox(tolerance uint8) (Rect, bool) {
+	if ppm.width == 0 || ppm.height == 0 {
+		return Rect{}, false
+	}
+	background := ppm.data[0][0]
+	return contentBounds(ppm.width, ppm.height, func(x, y int) bool {
+		p := ppm.data[y][x]
+		return withinTolerance(p.R, background.R, tolerance) && withinTolerance(p.G, background.G, tolerance) && withinTolerance(p.B, background.B, tolerance)
+	})
+}
+
+// Trim returns a new PPM image with the borders within tolerance of the top-left pixel
+// cropped away. A uniform image is returned unchanged, as a copy.
+func (ppm *PPM) Trim(tolerance uint8) *PPM {
+	r, ok := ppm.BoundingBox(tolerance)
+	if !ok {
+		r = Rect{0, 0, ppm.width, ppm.height}
+	} // Cropping everything would give a 0x0 image, which can't be saved and read back.
+	return ppm.Crop(r)
+}
@@ -0,0 +1,21 @@
+package Netpbm
+
+import "testing"
+
+// TestTrim checks that Trim crops uniform borders and keeps a uniform image whole.
+func TestTrim(t *testing.T) {
+	pgm := newPGM(6, 5, "P2", 255)
+	pgm.data[1][2], pgm.data[3][4] = 200, 100
+	if w, h := pgm.Trim(0).Size(); w != 3 || h != 3 {
+		t.Errorf("trimmed size = %dx%d, want 3x3", w, h)
+	}
+	if w, h := pgm.Trim(150).Size(); w != 1 || h != 1 {
+		t.Errorf("trimmed size with tolerance = %dx%d, want 1x1", w, h)
+	}
+	if w, h := newPPM(4, 2, "P3", 255).Trim(0).Size(); w != 4 || h != 2 {
+		t.Errorf("uniform PPM trimmed to %dx%d, want 4x2", w, h)
+	}
+	if w, h := newPBM(3, 3, "P1").Trim().Size(); w != 3 || h != 3 {
+		t.Errorf("uniform PBM trimmed to %dx%d, want 3x3", w, h)
+	}
+}
@@ -0,0 +1,309 @@
+package Netpbm
+
+import "math"
+
+// LineCap selects the shape drawn at the open ends of a stroke.
+type LineCap int
+
+const (
+	CapButt   LineCap = iota // The stroke stops at the end point.
+	CapRound                 // A half disk centered on the end point.
+	CapSquare                // The stroke extends half its width past the end point.
+)
+
+// LineJoin selects the shape drawn where two segments of a stroke meet.
+type LineJoin int
+
+const (
+	JoinMiter LineJoin = iota // The outer edges are extended until they meet, within the miter limit.
+	JoinRound                 // A disk centered on the vertex.
+	JoinBevel                 // The outer corners are connected by a straight edge.
+)
+
+// StrokeStyle describes how the outline of a shape is drawn.
+type StrokeStyle struct {
+	Width      float64   // Stroke width in pixels, 1 when zero.
+	Dash       []float64 // Alternating dash and gap lengths in pixels, a solid stroke when empty.
+	DashOffset float64   // Distance into the dash pattern at which the stroke starts.
+	Cap        LineCap   // Shape of the open ends, also used at the ends of each dash.
+	Join       LineJoin  // Shape of the corners.
+	MiterLimit float64   // Ratio of miter length to width above which miters are beveled, 4 when zero.
+}
+
+// withDefaults returns the style with its zero fields replaced by the defaults.
+func (s StrokeStyle) withDefaults() StrokeStyle {
+	if s.Width <= 0 {
+		s.Width = 1
+	}
+	if s.MiterLimit <= 0 {
+		s.MiterLimit = 4
+	}
+	return s
+}
+
+// vec is a point or a direction with real coordinates, used to build stroke outlines.
+type vec struct {
+	x, y float64
+}
+
+func toVec(p Point) vec                 { return vec{float64(p.X), float64(p.Y)} }
+func (a vec) add(b vec) vec             { return vec{a.x + b.x, a.y + b.y} }
+func (a vec) sub(b vec) vec             { return vec{a.x - b.x, a.y - b.y} }
+func (a vec) scale(k float64) vec       { return vec{a.x * k, a.y * k} }
+func (a vec) length() float64           { return math.Hypot(a.x, a.y) }
+func (a vec) cross(b vec) float64       { return a.x*b.y - a.y*b.x }
+func (a vec) normal() vec               { return vec{-a.y, a.x}.scale(1 / a.length()) }
+func (a vec) direction(b vec) vec       { return b.sub(a).scale(1 / b.sub(a).length()) }
+func (a vec) equals(b vec) bool         { return b.sub(a).length() < 1e-9 }
+func (a vec) lerp(b vec, t float64) vec { return a.add(b.sub(a).scale(t)) }
+
+// dashPath splits a path into the open sub-paths drawn by the dash pattern. A path without
+// dashes is returned as it is.
+func dashPath(path []vec, closed bool, dash []float64, offset float64) [][]vec {
+	total := 0.0
+	for _, d := range dash {
+		total += math.Max(d, 0)
+	}
+	if len(dash) == 0 || total <= 0 {
+		return [][]vec{path}
+	}
+	if len(dash)%2 == 1 {
+		dash = append(dash[:len(dash):len(dash)], dash...)
+	} // An odd pattern is repeated so that dashes and gaps alternate.
+	points := path
+	if closed {
+		points = append(path[:len(path):len(path)], path[0])
+	}
+
+	i, on, remaining := 0, true, math.Max(dash[0], 0)
+	for skip := math.Mod(math.Mod(offset, 2*total)+2*total, 2*total); skip > 0; { // Walk the pattern up to the offset.
+		if skip < remaining {
+			remaining -= skip
+			break
+		}
+		skip -= remaining
+		i, on = (i+1)%len(dash), !on
+		remaining = math.Max(dash[i], 0)
+	}
+
+	startsOn := on
+	var dashes [][]vec
+	var current []vec
+	if on {
+		current = []vec{points[0]}
+	}
+	for k := 0; k+1 < len(points); k++ {
+		a, b := points[k], points[k+1]
+		segment, pos := b.sub(a).length(), 0.0
+		for segment-pos > remaining {
+			pos += remaining
+			p := a.lerp(b, pos/segment)
+			if on {
+				dashes = append(dashes, append(current, p))
+				current = nil
+			} else {
+				current = []vec{p}
+			}
+			i, on = (i+1)%len(dash), !on
+			remaining = math.Max(dash[i], 0)
+		}
+		remaining -= segment - pos
+		if on {
+			current = append(current, b)
+		}
+	}
+	if on && len(current) > 0 {
+		if closed && startsOn && len(dashes) > 0 {
+			dashes[0] = append(current, dashes[0][1:]...) // The dash crossing the start of a closed path is one dash.
+		} else {
+			dashes = append(dashes, current)
+		}
+	}
+	return dashes
+}
+
+// diskPolygon approximates a disk with a regular polygon whose edges are about a pixel long.
+func diskPolygon(center vec, radius float64) []vec {
+	n := max(8, int(math.Ceil(2*math.Pi*radius)))
+	poly := make([]vec, n)
+	for i := range poly {
+		angle := 2 * math.Pi * float64(i) / float64(n)
+		poly[i] = center.add(vec{math.Cos(angle), math.Sin(angle)}.scale(radius))
+	}
+	return poly
+}
+
+// strokeShapes returns the convex polygons whose union is the stroke of the path: one
+// rectangle per segment, plus the caps at the ends of open paths and the joins at the corners.
+func strokeShapes(path []vec, closed bool, style StrokeStyle) [][]vec {
+	var points []vec
+	for _, p := range path {
+		if len(points) == 0 || !points[len(points)-1].equals(p) {
+			points = append(points, p)
+		}
+	} // Repeated points have no direction.
+	if closed && len(points) > 1 && points[0].equals(points[len(points)-1]) {
+		points = points[:len(points)-1]
+	}
+	hw := style.Width / 2
+	var shapes [][]vec
+
+	if len(points) == 1 { // A zero-length dash only shows through its caps.
+		p := points[0]
+		switch style.Cap {
+		case CapRound:
+			shapes = append(shapes, diskPolygon(p, hw))
+		case CapSquare:
+			shapes = append(shapes, []vec{{p.x - hw, p.y - hw}, {p.x + hw, p.y - hw}, {p.x + hw, p.y + hw}, {p.x - hw, p.y + hw}})
+		}
+		return shapes
+	}
+	if len(points) == 2 {
+		closed = false // A closed path of two points is a segment drawn twice.
+	}
+
+	n := len(points)
+	segments := n - 1
+	if closed {
+		segments = n
+	}
+	for k := 0; k < segments; k++ {
+		a, b := points[k], points[(k+1)%n]
+		d := a.direction(b)
+		if !closed && style.Cap == CapSquare {
+			if k == 0 {
+				a = a.sub(d.scale(hw))
+			}
+			if k == segments-1 {
+				b = b.add(d.scale(hw))
+			}
+		}
+		offset := d.normal().scale(hw)
+		shapes = append(shapes, []vec{a.add(offset), b.add(offset), b.sub(offset), a.sub(offset)})
+	}
+	if !closed && style.Cap == CapRound {
+		shapes = append(shapes, diskPolygon(points[0], hw), diskPolygon(points[n-1], hw))
+	}
+
+	for k := 0; k < n; k++ {
+		if !closed && (k == 0 || k == n-1) {
+			continue
+		} // Open ends get caps instead of joins.
+		prev, v, next := points[(k-1+n)%n], points[k], points[(k+1)%n]
+		in, out := prev.direction(v), v.direction(next)
+		turn := in.cross(out)
+		if math.Abs(turn) < 1e-9 && in.x*out.x+in.y*out.y > 0 {
+			continue
+		} // Straight through, the segment rectangles already meet.
+		side := 1.0
+		if turn > 0 {
+			side = -1
+		} // The join fills the outer side of the turn.
+		p1, p2 := v.add(in.normal().scale(side*hw)), v.add(out.normal().scale(side*hw))
+		switch style.Join {
+		case JoinRound:
+			shapes = append(shapes, diskPolygon(v, hw))
+		case JoinMiter:
+			m := in.normal().add(out.normal())
+			if l := m.length(); l > 1e-9 && 2/l <= style.MiterLimit { // 2/|m| is the miter length over the width.
+				tip := v.add(m.scale(side * 2 * hw / (l * l)))
+				shapes = append(shapes, []vec{v, p1, tip, p2})
+				continue
+			}
+			fallthrough
+		default:
+			shapes = append(shapes, []vec{v, p1, p2})
+		}
+	}
+	return shapes
+}
+
+// fillConvex marks in mask the pixels whose center lies in the convex polygon. Like
+// scanPolygon, coverage is half-open on both axes so that a shape of width w covers w pixels.
+func fillConvex(mask [][]bool, poly []vec) {
+	const epsilon = 1e-9
+	height := len(mask)
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, p := range poly {
+		minY, maxY = math.Min(minY, p.y), math.Max(maxY, p.y)
+	}
+	for y := max(int(math.Ceil(minY-epsilon)), 0); y < height && float64(y) < maxY-epsilon; y++ {
+		fy := float64(y)
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for i, a := range poly {
+			b := poly[(i+1)%len(poly)]
+			if (a.y <= fy) == (b.y <= fy) {
+				continue
+			} // Horizontal edges and edges off the scanline don't cross it.
+			x := a.x + (fy-a.y)*(b.x-a.x)/(b.y-a.y)
+			lo, hi = math.Min(lo, x), math.Max(hi, x)
+		}
+		for x := max(int(math.Ceil(lo-epsilon)), 0); x < len(mask[y]) && float64(x) < hi-epsilon; x++ {
+			mask[y][x] = true
+		}
+	}
+}
+
+// strokePath draws the stroke of the path with the style. The shapes are gathered in a mask
+// first so that every covered pixel is set once.
+func (ppm *PPM) strokePath(path []vec, closed bool, color Pixel, style StrokeStyle) {
+	if len(path) == 0 {
+		return
+	}
+	style = style.withDefaults()
+	mask := make([][]bool, ppm.height)
+	for y := range mask {
+		mask[y] = make([]bool, ppm.width)
+	}
+	dashes := dashPath(path, closed, style.Dash, style.DashOffset)
+	closed = closed && len(dashes) == 1 && len(dashes[0]) == len(path) // Dashing opens the path.
+	for _, dash := range dashes {
+		for _, shape := range strokeShapes(dash, closed, style) {
+			fillConvex(mask, shape)
+		}
+	}
+	for y := range mask {
+		for x, covered := range mask[y] {
+			if covered {
+				ppm.data[y][x] = color
+			}
+		}
+	}
+}
+
+// DrawStyledLine draws a line between two points with the stroke style.
+func (ppm *PPM) DrawStyledLine(p1, p2 Point, color Pixel, style StrokeStyle) {
+	ppm.strokePath([]vec{toVec(p1), toVec(p2)}, false, color, style)
+}
+
+// DrawStyledRectangle draws the outline of a rectangle with the stroke style, with the same
+// corners as DrawRectangle.
+func (ppm *PPM) DrawStyledRectangle(p1 Point, width, height int, color Pixel, style StrokeStyle) {
+	ppm.DrawStyledPolygon([]Point{p1, {p1.X + width, p1.Y}, {p1.X + width, p1.Y + height}, {p1.X, p1.Y + height}}, color, style)
+}
+
+// DrawStyledTriangle draws the outline of a triangle with the stroke style.
+func (ppm *PPM) DrawStyledTriangle(p1, p2, p3 Point, color Pixel, style StrokeStyle) {
+	ppm.DrawStyledPolygon([]Point{p1, p2, p3}, color, style)
+}
+
+// DrawStyledPolygon draws the closed outline of a polygon with the stroke style.
+func (ppm *PPM) DrawStyledPolygon(points []Point, color Pixel, style StrokeStyle) {
+	if len(points) < 3 {
+		return
+	}
+	path := make([]vec, len(points))
+	for i, p := range points {
+		path[i] = toVec(p)
+	}
+	ppm.strokePath(path, true, color, style)
+}
+
+// DrawStyledCircle draws a circle with the stroke style. Dashes are measured along the
+// circumference, starting from the rightmost point.
+func (ppm *PPM) DrawStyledCircle(center Point, radius int, color Pixel, style StrokeStyle) {
+	if radius <= 0 {
+		return
+	}
+	ppm.strokePath(diskPolygon(toVec(center), float64(radius)), true, color, style)
+}
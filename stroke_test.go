@@ -0,0 +1,37 @@
+package Netpbm
+
+import "testing"
+
+// TestStrokeWidth checks that a horizontal line of width w paints exactly w rows.
+func TestStrokeWidth(t *testing.T) {
+	for width := 1; width <= 4; width++ {
+		ppm := newPPM(16, 12, "P3", 255)
+		ppm.DrawStyledLine(Point{2, 5}, Point{12, 5}, Pixel{255, 0, 0}, StrokeStyle{Width: float64(width)})
+		rows := 0
+		for y := 0; y < ppm.height; y++ {
+			if ppm.data[y][7] != (Pixel{}) {
+				rows++
+			}
+		}
+		if rows != width {
+			t.Errorf("width %d: painted %d rows", width, rows)
+		}
+	}
+}
+
+// TestStrokeDash checks that butt-capped dashes and gaps have their exact lengths.
+func TestStrokeDash(t *testing.T) {
+	ppm := newPPM(16, 12, "P3", 255)
+	ppm.DrawStyledLine(Point{2, 5}, Point{12, 5}, Pixel{255, 0, 0}, StrokeStyle{Dash: []float64{2, 2}})
+	got := ""
+	for x := 0; x < ppm.width; x++ {
+		if ppm.data[5][x] != (Pixel{}) {
+			got += "#"
+		} else {
+			got += "."
+		}
+	}
+	if want := "..##..##..##...."; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
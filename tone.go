@@ -0,0 +1,206 @@
+package Netpbm
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Levels describes a Photoshop-style levels adjustment. Values are in image levels, from 0 to
+// the max value of the image.
+type Levels struct {
+	InputBlack, InputWhite   uint8   // Input values mapped to the output black and white points.
+	Gamma                    float64 // Midtone gamma, 1 leaves the midtones unchanged.
+	OutputBlack, OutputWhite uint8   // Output range of the adjustment.
+}
+
+// CurvePoint is a control point of a tone curve, mapping the input value In to Out. Both are
+// normalized to [0, 1].
+type CurvePoint struct {
+	In, Out float64
+}
+
+// toneLUT builds a lookup table for the values 0 to max from a function working on values
+// normalized to [0, 1].
+func toneLUT(max uint8, f func(v float64) float64) []uint8 {
+	lut := make([]uint8, int(max)+1)
+	for v := range lut {
+		n := 0.0
+		if max > 0 {
+			n = float64(v) / float64(max)
+		}
+		lut[v] = clampToMax(f(n)*float64(max), max)
+	}
+	return lut
+}
+
+// brightnessContrastLUT adds brightness and scales the distance to mid-gray by 1+contrast.
+// Both amounts go from -1 to 1.
+func brightnessContrastLUT(max uint8, brightness, contrast float64) []uint8 {
+	return toneLUT(max, func(v float64) float64 {
+		return (v-0.5)*(1+contrast) + 0.5 + brightness
+	})
+}
+
+// gammaLUT applies v^(1/gamma), so that gamma above 1 brightens the image.
+func gammaLUT(max uint8, gamma float64) []uint8 {
+	return toneLUT(max, func(v float64) float64 {
+		return math.Pow(v, 1/gamma)
+	})
+}
+
+// levelsLUT builds the lookup table of a levels adjustment.
+func levelsLUT(max uint8, l Levels) []uint8 {
+	if l.Gamma <= 0 {
+		l.Gamma = 1
+	}
+	m := float64(max)
+	inBlack, inWhite := float64(l.InputBlack)/m, float64(l.InputWhite)/m
+	outBlack, outWhite := float64(l.OutputBlack)/m, float64(l.OutputWhite)/m
+	return toneLUT(max, func(v float64) float64 {
+		if inWhite <= inBlack {
+			if v < inBlack {
+				return outBlack
+			}
+			return outWhite
+		} // A collapsed input range thresholds the image.
+		v = math.Min(math.Max((v-inBlack)/(inWhite-inBlack), 0), 1)
+		return outBlack + math.Pow(v, 1/l.Gamma)*(outWhite-outBlack)
+	})
+}
+
+// curveLUT builds the lookup table of a tone curve interpolated through the points with a
+// monotone cubic spline (Fritsch-Carlson), so that the curve never overshoots its points.
+func curveLUT(max uint8, points []CurvePoint) ([]uint8, error) {
+	if len(points) < 2 {
+		return nil, fmt.Errorf("a curve needs at least 2 points, got %d", len(points))
+	}
+	pts := append([]CurvePoint(nil), points...)
+	sort.Slice(pts, func(i, j int) bool { return pts[i].In < pts[j].In })
+	for i := 1; i < len(pts); i++ {
+		if pts[i].In == pts[i-1].In {
+			return nil, fmt.Errorf("curve points must have distinct inputs, %v is repeated", pts[i].In)
+		}
+	}
+
+	n := len(pts)
+	slopes := make([]float64, n-1) // Secant slopes between consecutive points.
+	for i := range slopes {
+		slopes[i] = (pts[i+1].Out - pts[i].Out) / (pts[i+1].In - pts[i].In)
+	}
+	tangents := make([]float64, n)
+	tangents[0], tangents[n-1] = slopes[0], slopes[n-2]
+	for i := 1; i < n-1; i++ {
+		if slopes[i-1]*slopes[i] <= 0 {
+			tangents[i] = 0
+		} else {
+			tangents[i] = (slopes[i-1] + slopes[i]) / 2
+		}
+	}
+	for i := range slopes {
+		if slopes[i] == 0 {
+			tangents[i], tangents[i+1] = 0, 0
+			continue
+		}
+		a, b := tangents[i]/slopes[i], tangents[i+1]/slopes[i]
+		if s := a*a + b*b; s > 9 {
+			t := 3 / math.Sqrt(s)
+			tangents[i], tangents[i+1] = t*a*slopes[i], t*b*slopes[i]
+		}
+	} // Limit the tangents so that each segment stays monotone.
+
+	return toneLUT(max, func(v float64) float64 {
+		if v <= pts[0].In {
+			return pts[0].Out
+		}
+		if v >= pts[n-1].In {
+			return pts[n-1].Out
+		}
+		i := sort.Search(n, func(i int) bool { return pts[i].In > v }) - 1
+		h := pts[i+1].In - pts[i].In
+		t := (v - pts[i].In) / h
+		t2, t3 := t*t, t*t*t
+		return (2*t3-3*t2+1)*pts[i].Out + (t3-2*t2+t)*h*tangents[i] +
+			(-2*t3+3*t2)*pts[i+1].Out + (t3-t2)*h*tangents[i+1] // Cubic Hermite basis.
+	}), nil
+}
+
+// applyPixelLUTs maps each channel of every pixel of the PPM image through its lookup table.
+func (ppm *PPM) applyPixelLUTs(r, g, b []uint8) {
+	for y := 0; y < ppm.height; y++ {
+		for x := 0; x < ppm.width; x++ {
+			p := ppm.data[y][x]
+			ppm.data[y][x] = Pixel{r[min(p.R, ppm.max)], g[min(p.G, ppm.max)], b[min(p.B, ppm.max)]}
+		}
+	}
+}
+
+// BrightnessContrast adjusts the PGM image. brightness shifts the values by that fraction of
+// max and contrast scales their distance to mid-gray by 1+contrast; both go from -1 to 1.
+func (pgm *PGM) BrightnessContrast(brightness, contrast float64) {
+	applyLUT(pgm.data, brightnessContrastLUT(pgm.max, brightness, contrast))
+}
+
+// Gamma applies a gamma correction to the PGM image, values above 1 brighten the midtones.
+func (pgm *PGM) Gamma(gamma float64) {
+	if gamma <= 0 {
+		return
+	}
+	applyLUT(pgm.data, gammaLUT(pgm.max, gamma))
+}
+
+// Levels applies a levels adjustment to the PGM image.
+func (pgm *PGM) Levels(l Levels) {
+	applyLUT(pgm.data, levelsLUT(pgm.max, l))
+}
+
+// Curves maps the values of the PGM image through the smooth tone curve passing through the
+// points, with inputs and outputs normalized to [0, 1].
+func (pgm *PGM) Curves(points []CurvePoint) error {
+	lut, err := curveLUT(pgm.max, points)
+	if err != nil {
+		return err
+	}
+	applyLUT(pgm.data, lut)
+	return nil
+}
+
+// BrightnessContrast adjusts every channel of the PPM image, see PGM.BrightnessContrast.
+func (ppm *PPM) BrightnessContrast(brightness, contrast float64) {
+	lut := brightnessContrastLUT(ppm.max, brightness, contrast)
+	ppm.applyPixelLUTs(lut, lut, lut)
+}
+
+// Gamma applies a gamma correction to every channel of the PPM image.
+func (ppm *PPM) Gamma(gamma float64) {
+	if gamma <= 0 {
+		return
+	}
+	lut := gammaLUT(ppm.max, gamma)
+	ppm.applyPixelLUTs(lut, lut, lut)
+}
+
+// Levels applies a levels adjustment to every channel of the PPM image.
+func (ppm *PPM) Levels(l Levels) {
+	lut := levelsLUT(ppm.max, l)
+	ppm.applyPixelLUTs(lut, lut, lut)
+}
+
+// Curves maps each channel of the PPM image through its own tone curve. A nil curve leaves
+// its channel unchanged.
+func (ppm *PPM) Curves(red, green, blue []CurvePoint) error {
+	var luts [3][]uint8
+	for c, points := range [3][]CurvePoint{red, green, blue} {
+		if points == nil {
+			luts[c] = toneLUT(ppm.max, func(v float64) float64 { return v })
+			continue
+		}
+		lut, err := curveLUT(ppm.max, points)
+		if err != nil {
+			return err
+		}
+		luts[c] = lut
+	}
+	ppm.applyPixelLUTs(luts[0], luts[1], luts[2])
+	return nil
+}
@@ -0,0 +1,209 @@
+package Netpbm
+
+import (
+	"fmt"
+	"math"
+)
+
+// Transform is a 3x3 projective transformation matrix in homogeneous coordinates.
+// It maps source coordinates (x, y, 1) to destination coordinates.
+type Transform [3][3]float64
+
+// Interpolation selects how pixel values are sampled between pixel centers.
+type Interpolation int
+
+const (
+	InterpolationNearest  Interpolation = iota // Take the value of the closest pixel.
+	InterpolationBilinear                      // Blend the four surrounding pixels.
+)
+
+// Identity returns the identity transform.
+func Identity() Transform {
+	return Transform{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+}
+
+// Affine returns the transform described by a 2x3 affine matrix.
+func Affine(m [2][3]float64) Transform {
+	return Transform{m[0], m[1], {0, 0, 1}}
+}
+
+// Apply maps the point (x, y) through the transform.
+func (t Transform) Apply(x, y float64) (float64, float64) {
+	w := t[2][0]*x + t[2][1]*y + t[2][2]
+	if w == 0 {
+		return math.Inf(1), math.Inf(1)
+	} // Points mapped to infinity can't be represented.
+	return (t[0][0]*x + t[0][1]*y + t[0][2]) / w, (t[1][0]*x + t[1][1]*y + t[1][2]) / w
+}
+
+// Multiply returns the transform that applies u first and then t.
+func (t Transform) Multiply(u Transform) Transform {
+	var r Transform
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				r[i][j] += t[i][k] * u[k][j]
+			}
+		}
+	}
+	return r
+}
+
+// Inverse returns the inverse transform and false if the matrix is singular.
+func (t Transform) Inverse() (Transform, bool) {
+	det := t[0][0]*(t[1][1]*t[2][2]-t[1][2]*t[2][1]) -
+		t[0][1]*(t[1][0]*t[2][2]-t[1][2]*t[2][0]) +
+		t[0][2]*(t[1][0]*t[2][1]-t[1][1]*t[2][0])
+	if math.Abs(det) < 1e-12 {
+		return Transform{}, false
+	}
+	inv := Transform{
+		{t[1][1]*t[2][2] - t[1][2]*t[2][1], t[0][2]*t[2][1] - t[0][1]*t[2][2], t[0][1]*t[1][2] - t[0][2]*t[1][1]},
+		{t[1][2]*t[2][0] - t[1][0]*t[2][2], t[0][0]*t[2][2] - t[0][2]*t[2][0], t[0][2]*t[1][0] - t[0][0]*t[1][2]},
+		{t[1][0]*t[2][1] - t[1][1]*t[2][0], t[0][1]*t[2][0] - t[0][0]*t[2][1], t[0][0]*t[1][1] - t[0][1]*t[1][0]},
+	} // Adjugate matrix, divided by the determinant below.
+	for i := range inv {
+		for j := range inv[i] {
+			inv[i][j] /= det
+		}
+	}
+	return inv, true
+}
+
+// HomographyFromPoints computes the homography that maps each src point onto the
+// dst point with the same index. It returns an error if the points are degenerate,
+// for example when three of them are collinear.
+func HomographyFromPoints(src, dst [4]Point) (Transform, error) {
+	var a [8][9]float64 // Augmented matrix of the 8x8 linear system, h22 is fixed to 1.
+	for i := 0; i < 4; i++ {
+		x, y := float64(src[i].X), float64(src[i].Y)
+		u, v := float64(dst[i].X), float64(dst[i].Y)
+		a[2*i] = [9]float64{x, y, 1, 0, 0, 0, -u * x, -u * y, u}
+		a[2*i+1] = [9]float64{0, 0, 0, x, y, 1, -v * x, -v * y, v}
+	}
+
+	for col := 0; col < 8; col++ { // Gaussian elimination with partial pivoting.
+		pivot := col
+		for row := col + 1; row < 8; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return Transform{}, fmt.Errorf("degenerate point correspondences")
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		for row := 0; row < 8; row++ {
+			if row == col {
+				continue
+			}
+			f := a[row][col] / a[col][col]
+			for k := col; k < 9; k++ {
+				a[row][k] -= f * a[col][k]
+			}
+		}
+	}
+
+	var h [8]float64
+	for i := range h {
+		h[i] = a[i][8] / a[i][i]
+	}
+	return Transform{{h[0], h[1], h[2]}, {h[3], h[4], h[5]}, {h[6], h[7], 1}}, nil
+}
+
+// sampleCoords returns the pixels surrounding the source position (sx, sy) and the
+// fractional weights of the second pixel on each axis. The boolean is false when the
+// position falls outside of a width x height image.
+func sampleCoords(sx, sy float64, width, height int, interp Interpolation) (x0, y0, x1, y1 int, fx, fy float64, ok bool) {
+	if math.IsNaN(sx) || math.IsNaN(sy) || sx < -0.5 || sy < -0.5 || sx > float64(width)-0.5 || sy > float64(height)-0.5 {
+		return 0, 0, 0, 0, 0, 0, false
+	}
+	if interp == InterpolationNearest {
+		x0, _ = borderIndex(int(math.Round(sx)), width, BorderClamp) // Round(-0.5) is -1.
+		y0, _ = borderIndex(int(math.Round(sy)), height, BorderClamp)
+		return x0, y0, x0, y0, 0, 0, true
+	}
+	fx0, fy0 := math.Floor(sx), math.Floor(sy)
+	fx, fy = sx-fx0, sy-fy0
+	x0, _ = borderIndex(int(fx0), width, BorderClamp)
+	y0, _ = borderIndex(int(fy0), height, BorderClamp)
+	x1, _ = borderIndex(int(fx0)+1, width, BorderClamp)
+	y1, _ = borderIndex(int(fy0)+1, height, BorderClamp)
+	return x0, y0, x1, y1, fx, fy, true
+}
+
+// bilinear blends the four corner values with the given fractional weights.
+func bilinear(v00, v10, v01, v11, fx, fy float64) float64 {
+	top := v00*(1-fx) + v10*fx
+	bottom := v01*(1-fx) + v11*fx
+	return top*(1-fy) + bottom*fy
+}
+
+// Warp applies the transform t to the PGM image. Every destination pixel is mapped
+// back into the source with the inverse transform and sampled with interp, pixels
+// that map outside of the source are set to fill. The image keeps its size.
+func (pgm *PGM) Warp(t Transform, interp Interpolation, fill uint8) error {
+	inv, ok := t.Inverse()
+	if !ok {
+		return fmt.Errorf("transform is not invertible")
+	}
+	out := newPGM(pgm.width, pgm.height, pgm.magicNumber, pgm.max)
+	for y := 0; y < pgm.height; y++ {
+		for x := 0; x < pgm.width; x++ {
+			sx, sy := inv.Apply(float64(x), float64(y))
+			x0, y0, x1, y1, fx, fy, ok := sampleCoords(sx, sy, pgm.width, pgm.height, interp)
+			if !ok {
+				out.data[y][x] = fill
+				continue
+			}
+			v := bilinear(float64(pgm.data[y0][x0]), float64(pgm.data[y0][x1]), float64(pgm.data[y1][x0]), float64(pgm.data[y1][x1]), fx, fy)
+			out.data[y][x] = clampToMax(v, pgm.max)
+		}
+	}
+	for y := range pgm.data {
+		copy(pgm.data[y], out.data[y])
+	} // Copy the rows back so that sub-image views keep sharing storage.
+	return nil
+}
+
+// Warp applies the transform t to the PPM image. Every destination pixel is mapped
+// back into the source with the inverse transform and sampled with interp, pixels
+// that map outside of the source are set to fill. The image keeps its size.
+func (ppm *PPM) Warp(t Transform, interp Interpolation, fill Pixel) error {
+	inv, ok := t.Inverse()
+	if !ok {
+		return fmt.Errorf("transform is not invertible")
+	}
+	out := newPPM(ppm.width, ppm.height, ppm.magicNumber, ppm.max)
+	for y := 0; y < ppm.height; y++ {
+		for x := 0; x < ppm.width; x++ {
+			sx, sy := inv.Apply(float64(x), float64(y))
+			x0, y0, x1, y1, fx, fy, ok := sampleCoords(sx, sy, ppm.width, ppm.height, interp)
+			if !ok {
+				out.data[y][x] = fill
+				continue
+			}
+			p00, p10, p01, p11 := ppm.data[y0][x0], ppm.data[y0][x1], ppm.data[y1][x0], ppm.data[y1][x1]
+			out.data[y][x] = Pixel{
+				R: clampToMax(bilinear(float64(p00.R), float64(p10.R), float64(p01.R), float64(p11.R), fx, fy), ppm.max),
+				G: clampToMax(bilinear(float64(p00.G), float64(p10.G), float64(p01.G), float64(p11.G), fx, fy), ppm.max),
+				B: clampToMax(bilinear(float64(p00.B), float64(p10.B), float64(p01.B), float64(p11.B), fx, fy), ppm.max),
+			} // Interpolate each channel separately.
+		}
+	}
+	for y := range ppm.data {
+		copy(ppm.data[y], out.data[y])
+	} // Copy the rows back so that sub-image views keep sharing storage.
+	return nil
+}
+
+// clampToMax rounds v to the nearest integer and clamps it to [0, max].
+func clampToMax(v float64, max uint8) uint8 {
+	if math.IsNaN(v) || v <= 0 {
+		return 0
+	}
+	if v >= float64(max) {
+		return max
+	}
+	return uint8(math.Round(v))
+}
@@ -0,0 +1,59 @@
+package Netpbm
+
+import "testing"
+
+// TestWarpHalfPixelShift checks that nearest neighbour sampling at exactly half a pixel outside
+// of the source clamps to the edge instead of indexing out of range.
+func TestWarpHalfPixelShift(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		t    Transform
+	}{
+		{"x", Affine([2][3]float64{{1, 0, 0.5}, {0, 1, 0}})},
+		{"y", Affine([2][3]float64{{1, 0, 0}, {0, 1, 0.5}})},
+	} {
+		pgm := newPGM(4, 3, "P2", 255)
+		ppm := newPPM(4, 3, "P3", 255)
+		for y := 0; y < 3; y++ {
+			for x := 0; x < 4; x++ {
+				pgm.data[y][x] = uint8(10*y + x + 1)
+				ppm.data[y][x] = Pixel{uint8(x + 1), uint8(y + 1), 7}
+			}
+		}
+		if err := pgm.Warp(tc.t, InterpolationNearest, 0); err != nil {
+			t.Fatalf("%s: PGM.Warp: %v", tc.name, err)
+		}
+		if err := ppm.Warp(tc.t, InterpolationNearest, Pixel{}); err != nil {
+			t.Fatalf("%s: PPM.Warp: %v", tc.name, err)
+		}
+		if got := pgm.data[0][0]; got != 1 {
+			t.Errorf("%s: PGM corner = %d, want 1 (edge pixel)", tc.name, got)
+		}
+		if got := ppm.data[0][0]; got != (Pixel{1, 1, 7}) {
+			t.Errorf("%s: PPM corner = %v, want {1 1 7} (edge pixel)", tc.name, got)
+		}
+	}
+}
+
+// TestWarpIdentity checks that the identity leaves the image unchanged with both interpolations.
+func TestWarpIdentity(t *testing.T) {
+	for _, interp := range []Interpolation{InterpolationNearest, InterpolationBilinear} {
+		pgm := newPGM(5, 4, "P2", 255)
+		for y := range pgm.data {
+			for x := range pgm.data[y] {
+				pgm.data[y][x] = uint8(x * y * 7)
+			}
+		}
+		want := pgm.Crop(Rect{0, 0, 5, 4})
+		if err := pgm.Warp(Identity(), interp, 0); err != nil {
+			t.Fatal(err)
+		}
+		for y := range pgm.data {
+			for x := range pgm.data[y] {
+				if pgm.data[y][x] != want.data[y][x] {
+					t.Fatalf("interp %d: pixel (%d, %d) = %d, want %d", interp, x, y, pgm.data[y][x], want.data[y][x])
+				}
+			}
+		}
+	}
+}